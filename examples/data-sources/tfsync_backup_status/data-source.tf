@@ -0,0 +1,20 @@
+# Copyright (c) HashiCorp, Inc.
+
+check "network_backup" {
+  data "tfsync_backup_status" "network" {
+    workspace_id = var.workspace_id
+    bucket       = var.bucket
+    key          = "statefiles/network/terraform.tfstate"
+    kms_key_id   = "alias/tfsync"
+  }
+
+  assert {
+    condition     = data.tfsync_backup_status.network.serial_lag == 0
+    error_message = "backup of the network workspace is ${data.tfsync_backup_status.network.serial_lag} serials behind"
+  }
+
+  assert {
+    condition     = data.tfsync_backup_status.network.kms_key_matches
+    error_message = "backup of the network workspace is not encrypted with alias/tfsync"
+  }
+}
@@ -0,0 +1,16 @@
+# Copyright (c) HashiCorp, Inc.
+
+data "tfsync_orphaned_backups" "example" {
+  organization = "my-org"
+  bucket       = var.bucket
+  prefix       = "statefiles/"
+  key_template = "statefiles/{organization}/{workspace_name}.tfstate"
+}
+
+output "orphaned_backup_keys" {
+  value = data.tfsync_orphaned_backups.example.orphans[*].key
+}
+
+output "workspaces_without_backup" {
+  value = data.tfsync_orphaned_backups.example.missing[*].workspace_name
+}
@@ -0,0 +1,10 @@
+# Copyright (c) HashiCorp, Inc.
+
+data "tfsync_s3_backup" "network" {
+  bucket = var.bucket
+  key    = "statefiles/network/terraform.tfstate"
+}
+
+locals {
+  vpc_id = jsondecode(data.tfsync_s3_backup.network.outputs["vpc_id"])
+}
@@ -0,0 +1,15 @@
+# Copyright (c) HashiCorp, Inc.
+
+data "tfsync_state_version" "network" {
+  organization   = var.organization
+  workspace_name = "network"
+}
+
+resource "terraform_data" "network_is_fresh" {
+  lifecycle {
+    precondition {
+      condition     = timecmp(data.tfsync_state_version.network.created_at, timeadd(plantimestamp(), "-24h")) > 0
+      error_message = "state of the network workspace is older than 24h"
+    }
+  }
+}
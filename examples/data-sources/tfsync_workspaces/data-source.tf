@@ -0,0 +1,18 @@
+# Copyright (c) HashiCorp, Inc.
+
+data "tfsync_workspaces" "production" {
+  organization         = "my-org"
+  workspace_name_regex = "^prod-"
+  tag_bindings = {
+    environment = "production"
+  }
+  has_state = true
+}
+
+resource "tfsync_s3_object" "production" {
+  for_each = data.tfsync_workspaces.production.ids
+
+  bucket       = var.bucket
+  key          = "statefiles/${each.key}/terraform.tfstate"
+  workspace_id = each.value
+}
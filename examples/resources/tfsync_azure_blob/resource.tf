@@ -0,0 +1,13 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_azure_blob" "network" {
+  workspace_id    = var.workspace_id
+  storage_account = "tfsyncbackups"
+  container       = "statefiles"
+  name            = "network/terraform.tfstate"
+  sas_token       = var.storage_sas_token
+
+  tags = {
+    team = "platform"
+  }
+}
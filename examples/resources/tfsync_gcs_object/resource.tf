@@ -0,0 +1,12 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_gcs_object" "network" {
+  workspace_id = var.workspace_id
+  bucket       = "tfsync-backups"
+  name         = "statefiles/network/terraform.tfstate"
+  kms_key_name = "projects/backups/locations/europe-west1/keyRings/tfsync/cryptoKeys/state"
+
+  metadata = {
+    team = "platform"
+  }
+}
@@ -0,0 +1,14 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_git_repository" "network" {
+  workspace_id = var.workspace_id
+  url          = "git@github.com:example/terraform-state.git"
+  branch       = "main"
+  path         = "{organization}/{workspace_name}/terraform.tfstate"
+
+  author_name    = "tfsync"
+  author_email   = "tfsync@example.com"
+  signing_key    = "/etc/tfsync/signing_key"
+  signing_format = "ssh"
+  ssh_command    = "ssh -i /etc/tfsync/deploy_key -o IdentitiesOnly=yes"
+}
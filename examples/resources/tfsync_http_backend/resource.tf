@@ -0,0 +1,13 @@
+# Copyright (c) HashiCorp, Inc.
+
+# GitLab-managed terraform state
+resource "tfsync_http_backend" "network" {
+  workspace_id   = var.workspace_id
+  address        = "https://gitlab.example.com/api/v4/projects/42/terraform/state/network"
+  lock_address   = "https://gitlab.example.com/api/v4/projects/42/terraform/state/network/lock"
+  unlock_address = "https://gitlab.example.com/api/v4/projects/42/terraform/state/network/lock"
+  lock_method    = "POST"
+  unlock_method  = "DELETE"
+  username       = "tfsync"
+  password       = var.gitlab_access_token
+}
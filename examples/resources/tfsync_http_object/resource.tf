@@ -0,0 +1,13 @@
+# Copyright (c) HashiCorp, Inc.
+
+# Nexus raw repository
+resource "tfsync_http_object" "network" {
+  workspace_id = var.workspace_id
+  url          = "https://nexus.example.com/repository/tfstate"
+  key          = "network/terraform.tfstate.gz"
+  compression  = "gzip"
+
+  headers = {
+    Authorization = "Bearer ${var.nexus_token}"
+  }
+}
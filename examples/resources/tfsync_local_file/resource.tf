@@ -0,0 +1,11 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_local_file" "network" {
+  workspace_id    = var.workspace_id
+  path            = "/mnt/backups/statefiles/network/terraform.tfstate.gz"
+  file_permission = "0640"
+  compression     = "gzip"
+
+  # base64 encoded 256-bit key, e.g. from `openssl rand -base64 32`
+  encryption_key = var.backup_encryption_key
+}
@@ -0,0 +1,8 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_oci_artifact" "network" {
+  workspace_id = var.workspace_id
+  repository   = "registry.example.com/terraform-state/network"
+  username     = "tfsync"
+  password     = var.registry_token
+}
@@ -0,0 +1,18 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_organization_backup" "this" {
+  organization   = var.organization
+  workspace_name = "prod-*"
+
+  bucket       = var.bucket
+  key_template = "statefiles/{workspace_name}/terraform.tfstate"
+  concurrency  = 8
+
+  # keep syncing the remaining workspaces when one fails, and only warn
+  failure_policy = "continue_and_warn"
+
+  # tags to apply to every s3 object
+  tags = {
+    MyTag = "MyValue"
+  }
+}
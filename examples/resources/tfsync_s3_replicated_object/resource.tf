@@ -0,0 +1,19 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_s3_replicated_object" "network" {
+  workspace_id = var.workspace_id
+
+  destination {
+    bucket = "tfsync-backups-eu"
+    key    = "network/terraform.tfstate"
+    region = "eu-west-1"
+  }
+
+  destination {
+    bucket     = "tfsync-backups-us"
+    key        = "network/terraform.tfstate"
+    region     = "us-east-1"
+    kms_key_id = "alias/tfsync"
+    role_arn   = "arn:aws:iam::123456789012:role/tfsync-backup"
+  }
+}
@@ -0,0 +1,23 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_state_migration" "network" {
+  source = {
+    hostname       = "tfe.example.com"
+    token          = var.tfe_token
+    organization   = "legacy"
+    workspace_name = "network"
+  }
+
+  destination = {
+    hostname       = "app.terraform.io"
+    token          = var.hcp_terraform_token
+    organization   = "my-org"
+    workspace_name = "network"
+  }
+
+  # copy every state version, oldest first
+  history = true
+
+  # stop runs in the source workspace from changing its state mid-copy
+  lock_source = true
+}
@@ -0,0 +1,11 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "tfsync_workspace_restore" "network" {
+  workspace_id = var.workspace_id
+  bucket       = var.bucket
+  key          = "statefiles/network/terraform.tfstate"
+  version_id   = var.backup_version_id
+
+  # refuse to restore unless the workspace id belongs to this workspace
+  confirm_workspace_name = "network"
+}
@@ -0,0 +1,206 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &AzureBlobResource{}
+
+func NewAzureBlobResource() resource.Resource {
+	return &AzureBlobResource{}
+}
+
+type AzureBlobResource struct {
+	destinationResource[AzureBlobResourceModel, *AzureBlobResourceModel, *azureBlobDestination]
+}
+
+type AzureBlobResourceModel struct {
+	destinationResourceModel
+
+	StorageAccount      types.String `tfsdk:"storage_account"`
+	Container           types.String `tfsdk:"container"`
+	Name                types.String `tfsdk:"name"`
+	Endpoint            types.String `tfsdk:"endpoint"`
+	ConnectionString    types.String `tfsdk:"connection_string"`
+	AccountKey          types.String `tfsdk:"account_key"`
+	SasToken            types.String `tfsdk:"sas_token"`
+	EncryptionScope     types.String `tfsdk:"encryption_scope"`
+	StateContentsSha256 types.String `tfsdk:"state_contents_sha256"`
+	BlobContentsSha256  types.String `tfsdk:"blob_contents_sha256"`
+	Tags                types.Map    `tfsdk:"tags"`
+}
+
+func (r *AzureBlobResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_azure_blob"
+}
+
+func (r *AzureBlobResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	credentials := path.Expressions{
+		path.MatchRoot("connection_string"),
+		path.MatchRoot("account_key"),
+		path.MatchRoot("sas_token"),
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to sync tf-state to an azure storage blob",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "workspace id, storage account, container and blob name",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"storage_account": schema.StringAttribute{
+				MarkdownDescription: "storage account name",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(3, 24),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"container": schema.StringAttribute{
+				MarkdownDescription: "storage container",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(3, 63),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "blob name",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 1024),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "blob service url, e.g. `http://127.0.0.1:10000/devstoreaccount1` for Azurite. Defaults to `https://<storage_account>.blob.core.windows.net/`",
+				Optional:            true,
+				Validators:          httpAddressValidators(),
+			},
+			"connection_string": schema.StringAttribute{
+				MarkdownDescription: "storage account connection string. Exactly one of `connection_string`, `account_key` or `sas_token` is required",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(credentials...),
+				},
+			},
+			"account_key": schema.StringAttribute{
+				MarkdownDescription: "storage account access key",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"sas_token": schema.StringAttribute{
+				MarkdownDescription: "shared access signature token with read, write, delete and tag permissions on the container",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"encryption_scope": schema.StringAttribute{
+				MarkdownDescription: "encryption scope the blob is encrypted with, e.g. one backed by a key vault key",
+				Optional:            true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state",
+				Computed:            true,
+			},
+			"blob_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of blob contents",
+				Computed:            true,
+			},
+			"ignore_empty": schema.BoolAttribute{
+				MarkdownDescription: "ignore if no state is found",
+				Optional:            true,
+			},
+			"ignored": schema.BoolAttribute{
+				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
+				Computed:            true,
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: "use soft delete",
+				Optional:            true,
+			},
+			"tags": schema.MapAttribute{
+				MarkdownDescription: "blob index tags",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (m *AzureBlobResourceModel) destination(ctx context.Context, maxRetries int) (*azureBlobDestination, diag.Diagnostics) {
+	return newAzureBlobDestination(azureBlobOptions{
+		StorageAccount:   m.StorageAccount.ValueString(),
+		Container:        m.Container.ValueString(),
+		Endpoint:         m.Endpoint.ValueString(),
+		ConnectionString: m.ConnectionString.ValueString(),
+		AccountKey:       m.AccountKey.ValueString(),
+		SasToken:         m.SasToken.ValueString(),
+		EncryptionScope:  m.EncryptionScope.ValueString(),
+	}, maxRetries)
+}
+
+func (m *AzureBlobResourceModel) id() string {
+	return fmt.Sprintf("%s/%s/%s/%s", m.WorkspaceId.ValueString(), m.StorageAccount.ValueString(), m.Container.ValueString(), m.Name.ValueString())
+}
+
+func (m *AzureBlobResourceModel) key() string {
+	return m.Name.ValueString()
+}
+
+func (m *AzureBlobResourceModel) location() string {
+	return fmt.Sprintf("container: %s, name: %s", m.Container.ValueString(), m.Name.ValueString())
+}
+
+func (m *AzureBlobResourceModel) tags(ctx context.Context) (tags map[string]string, diag diag.Diagnostics) {
+	diag.Append(m.Tags.ElementsAs(ctx, &tags, true)...)
+	return
+}
+
+func (m *AzureBlobResourceModel) clear() {
+	m.StateContentsSha256 = types.StringNull()
+	m.BlobContentsSha256 = types.StringNull()
+}
+
+func (m *AzureBlobResourceModel) setContents(dst *azureBlobDestination, state []byte, contents []byte) {
+	m.StateContentsSha256 = sha256Contents(state)
+	m.BlobContentsSha256 = sha256Contents(contents)
+}
@@ -0,0 +1,279 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &BackupStatusDataSource{}
+
+func NewBackupStatusDataSource() datasource.DataSource {
+	return &BackupStatusDataSource{}
+}
+
+type BackupStatusDataSource struct {
+	tfeClient  *tfe.Client
+	s3Clients  *s3ClientCache
+	kmsClient  *kms.Client
+	stateCache *stateCache
+}
+
+type BackupStatusDataSourceModel struct {
+	Id                   types.String `tfsdk:"id"`
+	WorkspaceId          types.String `tfsdk:"workspace_id"`
+	Bucket               types.String `tfsdk:"bucket"`
+	Key                  types.String `tfsdk:"key"`
+	KmsKeyId             types.String `tfsdk:"kms_key_id"`
+	BackupExists         types.Bool   `tfsdk:"backup_exists"`
+	BackupIsPlaceholder  types.Bool   `tfsdk:"backup_is_placeholder"`
+	InSync               types.Bool   `tfsdk:"in_sync"`
+	StateSerial          types.Int64  `tfsdk:"state_serial"`
+	BackupSerial         types.Int64  `tfsdk:"backup_serial"`
+	SerialLag            types.Int64  `tfsdk:"serial_lag"`
+	StateContentsSha256  types.String `tfsdk:"state_contents_sha256"`
+	BucketContentsSha256 types.String `tfsdk:"bucket_contents_sha256"`
+	BackupLastModified   types.String `tfsdk:"backup_last_modified"`
+	BackupAgeSeconds     types.Int64  `tfsdk:"backup_age_seconds"`
+	BackupKmsKeyId       types.String `tfsdk:"backup_kms_key_id"`
+	KmsKeyMatches        types.Bool   `tfsdk:"kms_key_matches"`
+}
+
+func (d *BackupStatusDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_backup_status"
+}
+
+func (d *BackupStatusDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Data source to compare a workspace's current tf-state with its s3 backup, e.g. from a `check` block",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "workspace id, bucket and key",
+				Computed:            true,
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"bucket": schema.StringAttribute{
+				MarkdownDescription: "s3 bucket",
+				Required:            true,
+				Validators:          s3BucketValidators(),
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "s3 bucket key",
+				Required:            true,
+				Validators:          s3KeyValidators(),
+			},
+			"kms_key_id": schema.StringAttribute{
+				MarkdownDescription: "kms key id, arn or alias the backup is expected to be encrypted with",
+				Optional:            true,
+				Validators:          kmsKeyIdValidators(),
+			},
+			"backup_exists": schema.BoolAttribute{
+				MarkdownDescription: "false if there is no object at `key`, in which case the other backup attributes are null",
+				Computed:            true,
+			},
+			"backup_is_placeholder": schema.BoolAttribute{
+				MarkdownDescription: "true if the object at `key` is a placeholder marker written while the workspace had no state, in which case `backup_serial` and `serial_lag` are null",
+				Computed:            true,
+			},
+			"in_sync": schema.BoolAttribute{
+				MarkdownDescription: "true if the backup contents match the current state",
+				Computed:            true,
+			},
+			"state_serial": schema.Int64Attribute{
+				MarkdownDescription: "serial of the current state",
+				Computed:            true,
+			},
+			"backup_serial": schema.Int64Attribute{
+				MarkdownDescription: "serial of the backup",
+				Computed:            true,
+			},
+			"serial_lag": schema.Int64Attribute{
+				MarkdownDescription: "number of serials the backup is behind the current state",
+				Computed:            true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state",
+				Computed:            true,
+			},
+			"bucket_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of s3 bucket object contents",
+				Computed:            true,
+			},
+			"backup_last_modified": schema.StringAttribute{
+				MarkdownDescription: "RFC3339 timestamp of when the backup was last written",
+				Computed:            true,
+			},
+			"backup_age_seconds": schema.Int64Attribute{
+				MarkdownDescription: "seconds since the backup was last written",
+				Computed:            true,
+			},
+			"backup_kms_key_id": schema.StringAttribute{
+				MarkdownDescription: "arn of the kms key the backup is encrypted with, null if it is not encrypted with kms",
+				Computed:            true,
+			},
+			"kms_key_matches": schema.BoolAttribute{
+				MarkdownDescription: "true if the backup is encrypted with `kms_key_id`, null if `kms_key_id` is not set",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *BackupStatusDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.tfeClient = data.tfeClient
+	d.s3Clients = data.s3Clients
+	d.kmsClient = data.kmsClient
+	d.stateCache = data.stateCache
+}
+
+func (d *BackupStatusDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.tfeClient == nil || d.s3Clients == nil || d.kmsClient == nil {
+		resp.Diagnostics.AddError("provider", "provider is not configured")
+		return
+	}
+
+	var data BackupStatusDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(d.read(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// read compares the workspace's current state with its backup in s3.
+func (d *BackupStatusDataSource) read(ctx context.Context, data *BackupStatusDataSourceModel) (diag diag.Diagnostics) {
+	bucket, key := data.Bucket.ValueString(), data.Key.ValueString()
+
+	contents, diags, _ := getStateFile(ctx, d.tfeClient, d.stateCache, data.WorkspaceId.ValueString(), false)
+	diag.Append(diags...)
+	if diag.HasError() {
+		return
+	}
+
+	state, err := parseStateFile(contents)
+	if err != nil {
+		diag.AddError("state", err.Error())
+		return
+	}
+
+	data.Id = types.StringValue(fmt.Sprintf("%s/%s/%s", data.WorkspaceId.ValueString(), bucket, key))
+	data.StateContentsSha256 = sha256Contents(contents)
+	data.StateSerial = types.Int64Value(state.Serial)
+
+	data.BackupExists = types.BoolValue(false)
+	data.BackupIsPlaceholder = types.BoolNull()
+	data.InSync = types.BoolValue(false)
+	data.BackupSerial = types.Int64Null()
+	data.SerialLag = types.Int64Null()
+	data.BucketContentsSha256 = types.StringNull()
+	data.BackupLastModified = types.StringNull()
+	data.BackupAgeSeconds = types.Int64Null()
+	data.BackupKmsKeyId = types.StringNull()
+	data.KmsKeyMatches = types.BoolNull()
+
+	// The contents and metadata come from the same response, so that they
+	// describe the same version of the object.
+	client, err := d.s3Clients.forBucket(ctx, bucket, s3ClientOptions{})
+	if err != nil {
+		diag.AddError("s3 client", err.Error())
+		return
+	}
+
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *s3types.NoSuchKey
+		if errors.As(err, &notFound) {
+			return
+		}
+
+		diag.AddError("s3 client", fmt.Sprintf("failed to get object: %s", err))
+		return
+	}
+	defer out.Body.Close()
+
+	backupContents, err := io.ReadAll(out.Body)
+	if err != nil {
+		diag.AddError("s3 client", fmt.Sprintf("failed to read body: %s", err))
+		return
+	}
+
+	data.BackupExists = types.BoolValue(true)
+	data.BucketContentsSha256 = sha256Contents(backupContents)
+	data.InSync = types.BoolValue(data.StateContentsSha256.Equal(data.BucketContentsSha256))
+
+	_, placeholder := parsePlaceholderMarker(backupContents)
+	data.BackupIsPlaceholder = types.BoolValue(placeholder)
+
+	if !placeholder {
+		backup, err := parseStateFile(backupContents)
+		if err != nil {
+			diag.AddError("state", fmt.Sprintf("s3://%s/%s: %s", bucket, key, err))
+			return
+		}
+
+		data.BackupSerial = types.Int64Value(backup.Serial)
+		data.SerialLag = types.Int64Value(max(state.Serial-backup.Serial, 0))
+	}
+
+	if out.LastModified != nil {
+		data.BackupLastModified = types.StringValue(out.LastModified.Format(time.RFC3339))
+		data.BackupAgeSeconds = types.Int64Value(int64(time.Since(*out.LastModified).Seconds()))
+	}
+
+	data.BackupKmsKeyId = types.StringPointerValue(out.SSEKMSKeyId)
+
+	if !data.KmsKeyId.IsNull() {
+		kmsKey, err := d.kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(data.KmsKeyId.ValueString())})
+		if err != nil {
+			diag.AddError("kms client", fmt.Sprintf("failed to describe key: %s", err))
+			return
+		}
+
+		data.KmsKeyMatches = types.BoolValue(out.SSEKMSKeyId != nil && aws.ToString(kmsKey.KeyMetadata.Arn) == aws.ToString(out.SSEKMSKeyId))
+	}
+
+	return
+}
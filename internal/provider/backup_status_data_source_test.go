@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestBackupStatus(t *testing.T) {
+	marker, err := newPlaceholderMarker("ws-abc", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, client := newFakeS3Client(t, &fakeS3Objects{objects: map[string][]byte{
+		"/backups/current":     []byte(fakeTfeState),
+		"/backups/old":         []byte(`{"version":4,"serial":1,"lineage":"lineage"}`),
+		"/backups/placeholder": marker,
+		"/backups/corrupted":   []byte("not a state"),
+	}})
+
+	d := &BackupStatusDataSource{
+		tfeClient:  newFakeTfeClient(t),
+		s3Clients:  newS3ClientCache(base, client),
+		stateCache: newStateCache(1<<20, ""),
+	}
+
+	type status struct {
+		exists      bool
+		placeholder types.Bool
+		inSync      bool
+		serial      types.Int64
+		lag         types.Int64
+	}
+
+	for key, want := range map[string]status{
+		"current":     {true, types.BoolValue(false), true, types.Int64Value(3), types.Int64Value(0)},
+		"old":         {true, types.BoolValue(false), false, types.Int64Value(1), types.Int64Value(2)},
+		"placeholder": {true, types.BoolValue(true), false, types.Int64Null(), types.Int64Null()},
+		"missing":     {false, types.BoolNull(), false, types.Int64Null(), types.Int64Null()},
+	} {
+		data := &BackupStatusDataSourceModel{
+			WorkspaceId: types.StringValue("ws-abc"),
+			Bucket:      types.StringValue("backups"),
+			Key:         types.StringValue(key),
+			KmsKeyId:    types.StringNull(),
+		}
+		if diags := d.read(context.Background(), data); diags.HasError() {
+			t.Errorf("%s: %v", key, diags)
+			continue
+		}
+
+		got := status{data.BackupExists.ValueBool(), data.BackupIsPlaceholder, data.InSync.ValueBool(), data.BackupSerial, data.SerialLag}
+		if got != want {
+			t.Errorf("%s: got %+v, want %+v", key, got, want)
+		}
+		if data.StateSerial.ValueInt64() != 3 {
+			t.Errorf("%s: got state serial %s, want 3", key, data.StateSerial)
+		}
+	}
+
+	// A backup that is neither a state nor a placeholder is an error.
+	data := &BackupStatusDataSourceModel{
+		WorkspaceId: types.StringValue("ws-abc"),
+		Bucket:      types.StringValue("backups"),
+		Key:         types.StringValue("corrupted"),
+		KmsKeyId:    types.StringNull(),
+	}
+	if diags := d.read(context.Background(), data); !diags.HasError() {
+		t.Error("got no error for a backup that is not a state")
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// destination is a store that tf-state is synced to. How a key is
+// interpreted is up to the implementation, e.g. as an s3 key or a file path.
+type destination interface {
+	// put writes contents to key, replacing any existing contents.
+	put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) diag.Diagnostics
+
+	// get reads the contents of key, or nil if key does not exist.
+	get(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+
+	// head reads the metadata of key, or nil if key does not exist.
+	head(ctx context.Context, key string) (*destinationObject, diag.Diagnostics)
+
+	// delete removes key. Deleting a key that does not exist is not an error.
+	delete(ctx context.Context, key string) diag.Diagnostics
+
+	// list returns every key beginning with prefix.
+	list(ctx context.Context, prefix string) ([]string, diag.Diagnostics)
+}
+
+// destinationMetadata is stored alongside the contents where the destination
+// supports it.
+type destinationMetadata struct {
+	WorkspaceId string
+	Tags        map[string]string
+
+	// WorkspaceName, Serial, Lineage and RunId describe the synced state
+	// version in destinations that keep a history, e.g. git commit messages.
+	WorkspaceName string
+	Serial        int64
+	Lineage       string
+	RunId         string
+}
+
+type destinationObject struct {
+	Size         int64
+	LastModified time.Time
+
+	// Sha256 is the hex encoded sha256 sum of the stored contents, or "" if
+	// the destination does not record one.
+	Sha256 string
+
+	// WorkspaceId is read back from destinationMetadata, or "" if the
+	// destination does not store metadata.
+	WorkspaceId string
+}
+
+const (
+	stateCompressionNone = "none"
+	stateCompressionGzip = "gzip"
+)
+
+// stateEncoding transforms state between its tfe and its stored form. The
+// zero value stores state as is.
+type stateEncoding struct {
+	Compression string
+
+	// EncryptionKey is a base64 encoded 256-bit AES-GCM key.
+	EncryptionKey string
+}
+
+func (e stateEncoding) encode(contents []byte) (encoded []byte, diag diag.Diagnostics) {
+	encoded = contents
+
+	if e.Compression == stateCompressionGzip {
+		var b bytes.Buffer
+		w := gzip.NewWriter(&b)
+		if _, err := w.Write(encoded); err != nil {
+			diag.AddError("encoding", fmt.Sprintf("failed to compress contents: %s", err))
+			return
+		}
+		if err := w.Close(); err != nil {
+			diag.AddError("encoding", fmt.Sprintf("failed to compress contents: %s", err))
+			return
+		}
+		encoded = b.Bytes()
+	}
+
+	if e.EncryptionKey != "" {
+		aead, d := newStateCipher(e.EncryptionKey)
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			diag.AddError("encoding", fmt.Sprintf("failed to generate nonce: %s", err))
+			return
+		}
+		encoded = aead.Seal(nonce, nonce, encoded, nil)
+	}
+
+	return
+}
+
+func (e stateEncoding) decode(encoded []byte) (contents []byte, diag diag.Diagnostics) {
+	contents = encoded
+
+	if e.EncryptionKey != "" {
+		aead, d := newStateCipher(e.EncryptionKey)
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+
+		if len(contents) < aead.NonceSize() {
+			diag.AddError("encoding", "failed to decrypt contents: contents are too short")
+			return
+		}
+
+		nonce, ciphertext := contents[:aead.NonceSize()], contents[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			diag.AddError("encoding", fmt.Sprintf("failed to decrypt contents: %s", err))
+			return
+		}
+		contents = plaintext
+	}
+
+	if e.Compression == stateCompressionGzip {
+		r, err := gzip.NewReader(bytes.NewReader(contents))
+		if err != nil {
+			diag.AddError("encoding", fmt.Sprintf("failed to decompress contents: %s", err))
+			return
+		}
+		defer r.Close()
+
+		contents, err = io.ReadAll(r)
+		if err != nil {
+			diag.AddError("encoding", fmt.Sprintf("failed to decompress contents: %s", err))
+			return
+		}
+	}
+
+	return
+}
+
+func newStateCipher(encodedKey string) (aead cipher.AEAD, diag diag.Diagnostics) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != 32 {
+		diag.AddError("encoding", "encryption key must be a base64 encoded 256-bit key")
+		return
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		diag.AddError("encoding", fmt.Sprintf("failed to create cipher: %s", err))
+		return
+	}
+
+	aead, err = cipher.NewGCM(block)
+	if err != nil {
+		diag.AddError("encoding", fmt.Sprintf("failed to create cipher: %s", err))
+		return
+	}
+
+	return
+}
+
+// putState writes state like writeState and checks that dst stored it intact.
+// stage is the sync stage that failed, if any.
+func putState(ctx context.Context, dst destination, key string, enc stateEncoding, state []byte, metadata destinationMetadata) (stage string, diag diag.Diagnostics) {
+	encoded, d := writeState(ctx, dst, key, enc, state, metadata)
+	diag.Append(d...)
+	if diag.HasError() {
+		return syncStageUpload, diag
+	}
+
+	diag.Append(verifyDestinationObject(ctx, dst, key, encoded)...)
+	if diag.HasError() {
+		return syncStageVerify, diag
+	}
+
+	return
+}
+
+// writeState encodes state and writes it to key, returning the encoded
+// contents.
+func writeState(ctx context.Context, dst destination, key string, enc stateEncoding, state []byte, metadata destinationMetadata) (encoded []byte, diag diag.Diagnostics) {
+	if len(state) == 0 {
+		diag.AddError("destination", fmt.Sprintf("refusing to write empty state to %s", key))
+		return
+	}
+
+	encoded, d := enc.encode(state)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	diag.Append(dst.put(ctx, key, encoded, metadata)...)
+
+	return
+}
+
+// getState reads and decodes key, returning nil if it does not exist.
+func getState(ctx context.Context, dst destination, key string, enc stateEncoding) (state []byte, diag diag.Diagnostics) {
+	encoded, d := dst.get(ctx, key)
+	diag.Append(d...)
+	if diag.HasError() || encoded == nil {
+		return
+	}
+
+	return enc.decode(encoded)
+}
+
+// verifyDestinationObject checks that key holds contents, where dst records
+// a checksum.
+func verifyDestinationObject(ctx context.Context, dst destination, key string, contents []byte) (diag diag.Diagnostics) {
+	obj, d := dst.head(ctx, key)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	if obj == nil {
+		diag.AddError("destination", fmt.Sprintf("%s does not exist after writing it", key))
+		return
+	}
+
+	if obj.Sha256 == "" {
+		return
+	}
+
+	if want := sha256Hex(contents); obj.Sha256 != want {
+		diag.AddError("destination", fmt.Sprintf("checksum mismatch for %s: expected sha256 %s, got %s", key, want, obj.Sha256))
+		return
+	}
+
+	return
+}
+
+func sha256Hex(contents []byte) string {
+	hash := sha256.Sum256(contents)
+	return hex.EncodeToString(hash[:])
+}
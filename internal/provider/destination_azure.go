@@ -0,0 +1,214 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+var _ destination = &azureBlobDestination{}
+
+const (
+	// azureMetadataWorkspaceId and azureMetadataSha256 are blob metadata
+	// keys. Azure requires metadata keys to be valid C# identifiers.
+	azureMetadataWorkspaceId = "tfsync_workspace_id"
+	azureMetadataSha256      = "tfsync_sha256"
+)
+
+// azureBlobOptions configures an azureBlobDestination. At most one of
+// ConnectionString, AccountKey and SasToken is set.
+type azureBlobOptions struct {
+	StorageAccount   string
+	Container        string
+	Endpoint         string
+	ConnectionString string
+	AccountKey       string
+	SasToken         string
+	EncryptionScope  string
+}
+
+// azureBlobDestination stores state as block blobs in an azure storage
+// container. Blobs do not record a sha256 sum, so the sum of the uploaded
+// contents is stored in the blob metadata instead.
+type azureBlobDestination struct {
+	client          *azblob.Client
+	container       string
+	encryptionScope string
+}
+
+func newAzureBlobDestination(opts azureBlobOptions, maxRetries int) (dst *azureBlobDestination, diag diag.Diagnostics) {
+	clientOpts := &azblob.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Retry: policy.RetryOptions{MaxRetries: int32(maxRetries)},
+		},
+	}
+
+	serviceURL := opts.Endpoint
+	if serviceURL == "" {
+		serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net/", opts.StorageAccount)
+	}
+
+	var client *azblob.Client
+	var err error
+
+	switch {
+	case opts.ConnectionString != "":
+		client, err = azblob.NewClientFromConnectionString(opts.ConnectionString, clientOpts)
+	case opts.AccountKey != "":
+		cred, cerr := azblob.NewSharedKeyCredential(opts.StorageAccount, opts.AccountKey)
+		if cerr != nil {
+			diag.AddError("azure client", fmt.Sprintf("failed to create shared key credential: %s", cerr))
+			return
+		}
+		client, err = azblob.NewClientWithSharedKeyCredential(serviceURL, cred, clientOpts)
+	case opts.SasToken != "":
+		client, err = azblob.NewClientWithNoCredential(strings.TrimSuffix(serviceURL, "/")+"/?"+strings.TrimPrefix(opts.SasToken, "?"), clientOpts)
+	default:
+		diag.AddError("azure client", "one of connection_string, account_key or sas_token is required")
+		return
+	}
+	if err != nil {
+		diag.AddError("azure client", fmt.Sprintf("failed to create client: %s", err))
+		return
+	}
+
+	dst = &azureBlobDestination{client: client, container: opts.Container, encryptionScope: opts.EncryptionScope}
+	return
+}
+
+func (d *azureBlobDestination) put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) (diag diag.Diagnostics) {
+	hash := md5.Sum(contents)
+	sha := sha256Hex(contents)
+
+	blobMetadata := map[string]*string{azureMetadataSha256: &sha}
+	if metadata.WorkspaceId != "" {
+		blobMetadata[azureMetadataWorkspaceId] = &metadata.WorkspaceId
+	}
+
+	// State fits in a single put blob request, whose transactional md5 the
+	// service checks against the contents it received. UploadBuffer only
+	// validates the blocks of chunked uploads, so the block blob is put
+	// directly.
+	contentType := "application/json"
+	opts := &blockblob.UploadOptions{
+		Metadata:                blobMetadata,
+		Tags:                    metadata.Tags,
+		TransactionalValidation: blob.TransferValidationTypeMD5(hash[:]),
+		HTTPHeaders: &blob.HTTPHeaders{
+			BlobContentMD5:  hash[:],
+			BlobContentType: &contentType,
+		},
+	}
+	if d.encryptionScope != "" {
+		opts.CPKScopeInfo = &blob.CPKScopeInfo{EncryptionScope: &d.encryptionScope}
+	}
+
+	client := d.client.ServiceClient().NewContainerClient(d.container).NewBlockBlobClient(key)
+	if _, err := client.Upload(ctx, streaming.NopCloser(bytes.NewReader(contents)), opts); err != nil {
+		diag.AddError("azure client", fmt.Sprintf("failed to upload blob: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *azureBlobDestination) get(ctx context.Context, key string) (contents []byte, diag diag.Diagnostics) {
+	resp, err := d.client.DownloadStream(ctx, d.container, key, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return
+		}
+
+		diag.AddError("azure client", fmt.Sprintf("failed to download blob: %s", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	contents, err = io.ReadAll(resp.Body)
+	if err != nil {
+		diag.AddError("azure client", fmt.Sprintf("failed to read body: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *azureBlobDestination) head(ctx context.Context, key string) (obj *destinationObject, diag diag.Diagnostics) {
+	resp, err := d.client.ServiceClient().NewContainerClient(d.container).NewBlobClient(key).GetProperties(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return
+		}
+
+		diag.AddError("azure client", fmt.Sprintf("failed to get blob properties: %s", err))
+		return
+	}
+
+	obj = &destinationObject{
+		Sha256:      azureMetadataValue(resp.Metadata, azureMetadataSha256),
+		WorkspaceId: azureMetadataValue(resp.Metadata, azureMetadataWorkspaceId),
+	}
+	if resp.ContentLength != nil {
+		obj.Size = *resp.ContentLength
+	}
+	if resp.LastModified != nil {
+		obj.LastModified = *resp.LastModified
+	}
+
+	return
+}
+
+func (d *azureBlobDestination) delete(ctx context.Context, key string) (diag diag.Diagnostics) {
+	if _, err := d.client.DeleteBlob(ctx, d.container, key, nil); err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		diag.AddError("azure client", fmt.Sprintf("failed to delete blob: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *azureBlobDestination) list(ctx context.Context, prefix string) (keys []string, diag diag.Diagnostics) {
+	pager := d.client.NewListBlobsFlatPager(d.container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			diag.AddError("azure client", fmt.Sprintf("failed to list blobs: %s", err))
+			return
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil {
+				keys = append(keys, *item.Name)
+			}
+		}
+	}
+
+	return
+}
+
+// azureMetadataValue looks up key in blob metadata. The case of metadata keys
+// is not preserved by every service version, so the lookup ignores case.
+func azureMetadataValue(metadata map[string]*string, key string) string {
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) && v != nil {
+			return *v
+		}
+	}
+	return ""
+}
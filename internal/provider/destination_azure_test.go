@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAzureBlob is the blob service of a storage account with a single
+// container, "backups". Like the real service it rejects uploads whose
+// Content-MD5 header does not match the body, and it also rejects uploads
+// without one so tests notice when the client stops sending it.
+type fakeAzureBlob struct {
+	mu       sync.Mutex
+	blobs    map[string][]byte
+	metadata map[string]http.Header
+}
+
+func (f *fakeAzureBlob) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/backups")
+	if !ok {
+		f.error(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+	key = strings.TrimPrefix(key, "/")
+
+	if key == "" && r.URL.Query().Get("comp") == "list" && r.Method == http.MethodGet {
+		prefix := r.URL.Query().Get("prefix")
+		var names []string
+		for name := range f.blobs {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="backups"><Blobs>`)
+		for _, name := range names {
+			fmt.Fprintf(w, `<Blob><Name>%s</Name><Properties><BlobType>BlockBlob</BlobType></Properties></Blob>`, name)
+		}
+		fmt.Fprint(w, `</Blobs><NextMarker/></EnumerationResults>`)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			f.error(w, http.StatusBadRequest, "InvalidInput")
+			return
+		}
+		hash := md5.Sum(body)
+		if r.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(hash[:]) {
+			f.error(w, http.StatusBadRequest, "Md5Mismatch")
+			return
+		}
+
+		metadata := make(http.Header)
+		for k, v := range r.Header {
+			if strings.HasPrefix(strings.ToLower(k), "x-ms-meta-") {
+				metadata[k] = v
+			}
+		}
+		f.blobs[key] = body
+		f.metadata[key] = metadata
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodHead, http.MethodGet:
+		body, ok := f.blobs[key]
+		if !ok {
+			f.error(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		for k, v := range f.metadata[key] {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("x-ms-blob-type", "BlockBlob")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(body)
+		}
+
+	case http.MethodDelete:
+		if _, ok := f.blobs[key]; !ok {
+			f.error(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(f.blobs, key)
+		delete(f.metadata, key)
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		f.error(w, http.StatusMethodNotAllowed, "UnsupportedHttpVerb")
+	}
+}
+
+func (f *fakeAzureBlob) error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("x-ms-error-code", code)
+	w.WriteHeader(status)
+}
+
+func TestAzureBlobDestination(t *testing.T) {
+	f := &fakeAzureBlob{blobs: make(map[string][]byte), metadata: make(map[string]http.Header)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	dst, diags := newAzureBlobDestination(azureBlobOptions{
+		StorageAccount: "account",
+		Container:      "backups",
+		Endpoint:       srv.URL,
+		SasToken:       "sv=2020-02-10&sig=signature",
+	}, 0)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	ctx := context.Background()
+
+	state := []byte(`{"serial":3}`)
+	if diags := dst.put(ctx, "network/terraform.tfstate", state, destinationMetadata{WorkspaceId: "ws-abc"}); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	obj, diags := dst.head(ctx, "network/terraform.tfstate")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if obj == nil || obj.Sha256 != sha256Hex(state) || obj.WorkspaceId != "ws-abc" || obj.Size != int64(len(state)) {
+		t.Fatalf("got %+v, want the state of ws-abc", obj)
+	}
+
+	contents, diags := dst.get(ctx, "network/terraform.tfstate")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if !bytes.Equal(contents, state) {
+		t.Errorf("got %q, want %q", contents, state)
+	}
+
+	keys, diags := dst.list(ctx, "network/")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if want := []string{"network/terraform.tfstate"}; !slices.Equal(keys, want) {
+		t.Errorf("got keys %q, want %q", keys, want)
+	}
+
+	if diags := dst.delete(ctx, "network/terraform.tfstate"); diags.HasError() {
+		t.Fatal(diags)
+	}
+	// Deleting a missing blob is not an error.
+	if diags := dst.delete(ctx, "network/terraform.tfstate"); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if obj, diags := dst.head(ctx, "network/terraform.tfstate"); diags.HasError() || obj != nil {
+		t.Errorf("got %+v (%v) after delete, want nothing", obj, diags)
+	}
+	if contents, diags := dst.get(ctx, "network/terraform.tfstate"); diags.HasError() || contents != nil {
+		t.Errorf("got %q (%v) after delete, want nothing", contents, diags)
+	}
+}
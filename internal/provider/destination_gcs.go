@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+var _ destination = &gcsDestination{}
+
+const (
+	gcsMetadataWorkspaceId = "tfsync-workspace-id"
+	gcsMetadataSha256      = "tfsync-sha256"
+)
+
+// gcsOptions configures a gcsDestination. Without Credentials, AccessToken
+// or Anonymous, application default credentials are used.
+type gcsOptions struct {
+	Bucket      string
+	Endpoint    string
+	Credentials string
+	AccessToken string
+	Anonymous   bool
+	KmsKeyName  string
+}
+
+// gcsDestination stores state as objects in a gcs bucket. Objects do not
+// record a sha256 sum, so the sum of the uploaded contents is stored in the
+// object metadata instead. close must be called when done.
+type gcsDestination struct {
+	client     *storage.Client
+	bucket     *storage.BucketHandle
+	kmsKeyName string
+}
+
+func newGCSDestination(ctx context.Context, opts gcsOptions, maxRetries int) (dst *gcsDestination, diag diag.Diagnostics) {
+	var clientOpts []option.ClientOption
+	if opts.Endpoint != "" {
+		clientOpts = append(clientOpts, option.WithEndpoint(opts.Endpoint))
+	}
+
+	switch {
+	case opts.Anonymous:
+		clientOpts = append(clientOpts, option.WithoutAuthentication())
+	case opts.Credentials != "":
+		clientOpts = append(clientOpts, option.WithCredentialsJSON([]byte(opts.Credentials)))
+	case opts.AccessToken != "":
+		clientOpts = append(clientOpts, option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: opts.AccessToken})))
+	}
+
+	client, err := storage.NewClient(ctx, clientOpts...)
+	if err != nil {
+		diag.AddError("gcs client", fmt.Sprintf("failed to create client: %s", err))
+		return
+	}
+	client.SetRetry(storage.WithMaxAttempts(maxRetries + 1))
+
+	dst = &gcsDestination{client: client, bucket: client.Bucket(opts.Bucket), kmsKeyName: opts.KmsKeyName}
+	return
+}
+
+func (d *gcsDestination) close() {
+	d.client.Close()
+}
+
+func (d *gcsDestination) put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) (diag diag.Diagnostics) {
+	hash := md5.Sum(contents)
+
+	// The reserved keys are set last so user metadata cannot replace them.
+	objectMetadata := make(map[string]string, len(metadata.Tags)+2)
+	for k, v := range metadata.Tags {
+		objectMetadata[k] = v
+	}
+	objectMetadata[gcsMetadataSha256] = sha256Hex(contents)
+	delete(objectMetadata, gcsMetadataWorkspaceId)
+	if metadata.WorkspaceId != "" {
+		objectMetadata[gcsMetadataWorkspaceId] = metadata.WorkspaceId
+	}
+
+	w := d.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = "application/json"
+	w.Metadata = objectMetadata
+	w.KMSKeyName = d.kmsKeyName
+	// gcs rejects the upload if the contents it received do not match.
+	w.MD5 = hash[:]
+
+	if _, err := w.Write(contents); err != nil {
+		w.Close()
+		diag.AddError("gcs client", fmt.Sprintf("failed to write object: %s", err))
+		return
+	}
+
+	if err := w.Close(); err != nil {
+		diag.AddError("gcs client", fmt.Sprintf("failed to write object: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *gcsDestination) get(ctx context.Context, key string) (contents []byte, diag diag.Diagnostics) {
+	r, err := d.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return
+		}
+
+		diag.AddError("gcs client", fmt.Sprintf("failed to read object: %s", err))
+		return
+	}
+	defer r.Close()
+
+	contents, err = io.ReadAll(r)
+	if err != nil {
+		diag.AddError("gcs client", fmt.Sprintf("failed to read body: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *gcsDestination) head(ctx context.Context, key string) (obj *destinationObject, diag diag.Diagnostics) {
+	attrs, err := d.bucket.Object(key).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return
+		}
+
+		diag.AddError("gcs client", fmt.Sprintf("failed to read object attributes: %s", err))
+		return
+	}
+
+	obj = &destinationObject{
+		Size:         attrs.Size,
+		LastModified: attrs.Updated,
+		Sha256:       attrs.Metadata[gcsMetadataSha256],
+		WorkspaceId:  attrs.Metadata[gcsMetadataWorkspaceId],
+	}
+
+	return
+}
+
+func (d *gcsDestination) delete(ctx context.Context, key string) (diag diag.Diagnostics) {
+	if err := d.bucket.Object(key).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		diag.AddError("gcs client", fmt.Sprintf("failed to delete object: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *gcsDestination) list(ctx context.Context, prefix string) (keys []string, diag diag.Diagnostics) {
+	it := d.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			diag.AddError("gcs client", fmt.Sprintf("failed to list objects: %s", err))
+			return
+		}
+
+		keys = append(keys, attrs.Name)
+	}
+
+	return
+}
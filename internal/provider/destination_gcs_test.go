@@ -0,0 +1,207 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGCSObject is the json representation of an object.
+type fakeGCSObject struct {
+	Bucket      string            `json:"bucket"`
+	Name        string            `json:"name"`
+	Size        string            `json:"size"`
+	ContentType string            `json:"contentType"`
+	Md5Hash     string            `json:"md5Hash"`
+	Metadata    map[string]string `json:"metadata"`
+	Updated     string            `json:"updated"`
+}
+
+// fakeGCS serves the json and xml apis of gcs for the bucket "backups". Like
+// the real service it rejects multipart uploads whose md5Hash does not match
+// the contents, and it also rejects uploads without one.
+type fakeGCS struct {
+	mu       sync.Mutex
+	objects  map[string]fakeGCSObject
+	contents map[string][]byte
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/backups/o":
+		obj, contents, err := readGCSMultipart(r)
+		if err != nil {
+			f.error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		hash := md5.Sum(contents)
+		if obj.Md5Hash != base64.StdEncoding.EncodeToString(hash[:]) {
+			f.error(w, http.StatusBadRequest, "md5 mismatch")
+			return
+		}
+
+		obj.Bucket = "backups"
+		obj.Name = r.URL.Query().Get("name")
+		obj.Size = strconv.Itoa(len(contents))
+		obj.Updated = time.Now().UTC().Format(time.RFC3339)
+		f.objects[obj.Name] = obj
+		f.contents[obj.Name] = contents
+		_ = json.NewEncoder(w).Encode(obj)
+
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/backups/o":
+		var items []fakeGCSObject
+		for _, name := range slices.Sorted(maps.Keys(f.objects)) {
+			if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+				items = append(items, f.objects[name])
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"kind": "storage#objects", "items": items})
+
+	case strings.HasPrefix(r.URL.Path, "/storage/v1/b/backups/o/"):
+		name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/backups/o/")
+		obj, ok := f.objects[name]
+		if !ok {
+			f.error(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(obj)
+		case http.MethodDelete:
+			delete(f.objects, name)
+			delete(f.contents, name)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			f.error(w, http.StatusMethodNotAllowed, "unexpected method")
+		}
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/backups/"):
+		contents, ok := f.contents[strings.TrimPrefix(r.URL.Path, "/backups/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+		_, _ = w.Write(contents)
+
+	default:
+		f.error(w, http.StatusNotFound, "unexpected request")
+	}
+}
+
+func (f *fakeGCS) error(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": status, "message": message}})
+}
+
+// readGCSMultipart reads the object resource and contents of a multipart
+// upload.
+func readGCSMultipart(r *http.Request) (obj fakeGCSObject, contents []byte, err error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+
+	part, err := mr.NextPart()
+	if err != nil {
+		return
+	}
+	if err = json.NewDecoder(part).Decode(&obj); err != nil {
+		return
+	}
+
+	part, err = mr.NextPart()
+	if err != nil {
+		return
+	}
+	contents, err = io.ReadAll(part)
+	return
+}
+
+func TestGCSDestination(t *testing.T) {
+	f := &fakeGCS{objects: make(map[string]fakeGCSObject), contents: make(map[string][]byte)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	dst, diags := newGCSDestination(ctx, gcsOptions{Bucket: "backups", Endpoint: srv.URL + "/storage/v1/", Anonymous: true}, 0)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	t.Cleanup(dst.close)
+
+	// User metadata must not replace the keys the destination reads back.
+	state := []byte(`{"serial":3}`)
+	metadata := destinationMetadata{
+		WorkspaceId: "ws-abc",
+		Tags:        map[string]string{"team": "network", gcsMetadataSha256: "forged", gcsMetadataWorkspaceId: "ws-forged"},
+	}
+	if diags := dst.put(ctx, "network/terraform.tfstate", state, metadata); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got := f.objects["network/terraform.tfstate"].Metadata["team"]; got != "network" {
+		t.Errorf("got metadata team %q, want network", got)
+	}
+
+	obj, diags := dst.head(ctx, "network/terraform.tfstate")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if obj == nil || obj.Sha256 != sha256Hex(state) || obj.WorkspaceId != "ws-abc" || obj.Size != int64(len(state)) {
+		t.Fatalf("got %+v, want the state of ws-abc", obj)
+	}
+
+	contents, diags := dst.get(ctx, "network/terraform.tfstate")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if !bytes.Equal(contents, state) {
+		t.Errorf("got %q, want %q", contents, state)
+	}
+
+	keys, diags := dst.list(ctx, "network/")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if want := []string{"network/terraform.tfstate"}; !slices.Equal(keys, want) {
+		t.Errorf("got keys %q, want %q", keys, want)
+	}
+
+	if diags := dst.delete(ctx, "network/terraform.tfstate"); diags.HasError() {
+		t.Fatal(diags)
+	}
+	// Deleting a missing object is not an error.
+	if diags := dst.delete(ctx, "network/terraform.tfstate"); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if obj, diags := dst.head(ctx, "network/terraform.tfstate"); diags.HasError() || obj != nil {
+		t.Errorf("got %+v (%v) after delete, want nothing", obj, diags)
+	}
+	if contents, diags := dst.get(ctx, "network/terraform.tfstate"); diags.HasError() || contents != nil {
+		t.Errorf("got %q (%v) after delete, want nothing", contents, diags)
+	}
+}
@@ -0,0 +1,485 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+var _ destination = &gitDestination{}
+
+const (
+	defaultGitBranch      = "main"
+	defaultGitAuthorName  = "tfsync"
+	defaultGitAuthorEmail = "tfsync@localhost"
+	defaultGitPushRetries = 3
+
+	// gitDeepenStep is how many commits of history are fetched first when
+	// looking for the last commit of a key, doubling on every attempt.
+	gitDeepenStep = 16
+
+	gitSigningFormatOpenPGP = "openpgp"
+	gitSigningFormatSSH     = "ssh"
+)
+
+// gitOptions configures a gitDestination.
+type gitOptions struct {
+	URL         string
+	Branch      string
+	AuthorName  string
+	AuthorEmail string
+
+	// SigningKey signs commits when set, as understood by git's
+	// user.signingKey in SigningFormat.
+	SigningKey    string
+	SigningFormat string
+
+	// SSHCommand overrides the ssh command used for ssh remotes.
+	SSHCommand string
+
+	// PushRetries is how often a push rejected as non-fast-forward is
+	// retried on top of the new branch tip.
+	PushRetries int
+}
+
+// gitDestination commits state to a branch of a git repository. Keys are
+// paths within the repository. Every operation works on a fresh shallow
+// clone, so concurrent writers only ever conflict on push.
+type gitDestination struct {
+	opts gitOptions
+
+	// commit is the last commit touching the key of the latest put, get or
+	// delete, or "" if there is none.
+	commit string
+}
+
+// gitError is returned for a failed git command, carrying its stderr.
+type gitError struct {
+	args   []string
+	stderr string
+	err    error
+}
+
+func (e *gitError) Error() string {
+	return fmt.Sprintf("git %s: %s: %s", strings.Join(e.args, " "), e.err, strings.TrimSpace(e.stderr))
+}
+
+func newGitDestination(opts gitOptions) *gitDestination {
+	if opts.Branch == "" {
+		opts.Branch = defaultGitBranch
+	}
+	if opts.AuthorName == "" {
+		opts.AuthorName = defaultGitAuthorName
+	}
+	if opts.AuthorEmail == "" {
+		opts.AuthorEmail = defaultGitAuthorEmail
+	}
+	if opts.SigningFormat == "" {
+		opts.SigningFormat = gitSigningFormatOpenPGP
+	}
+
+	return &gitDestination{opts: opts}
+}
+
+func (d *gitDestination) git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_AUTHOR_NAME="+d.opts.AuthorName,
+		"GIT_AUTHOR_EMAIL="+d.opts.AuthorEmail,
+		"GIT_COMMITTER_NAME="+d.opts.AuthorName,
+		"GIT_COMMITTER_EMAIL="+d.opts.AuthorEmail,
+	)
+	if d.opts.SSHCommand != "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND="+d.opts.SSHCommand)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", &gitError{args: args, stderr: stderr.String(), err: err}
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// checkout makes a shallow clone of the branch tip in a new temporary
+// directory. The branch does not have to exist yet.
+func (d *gitDestination) checkout(ctx context.Context) (dir string, err error) {
+	dir, err = os.MkdirTemp("", "tfsync-git-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = d.git(ctx, dir, "init", "--quiet"); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	if _, err = d.git(ctx, dir, "remote", "add", "origin", d.opts.URL); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	if err = d.reset(ctx, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// reset points the work tree of dir at the current remote branch tip, or at
+// an empty orphan branch if it does not exist yet.
+func (d *gitDestination) reset(ctx context.Context, dir string) error {
+	ref := "refs/heads/" + d.opts.Branch
+
+	heads, err := d.git(ctx, dir, "ls-remote", "--heads", "origin", ref)
+	if err != nil {
+		return err
+	}
+
+	if heads == "" {
+		_, err = d.git(ctx, dir, "checkout", "--quiet", "--orphan", d.opts.Branch)
+		return err
+	}
+
+	if _, err = d.git(ctx, dir, "fetch", "--quiet", "--depth", "1", "origin", ref); err != nil {
+		return err
+	}
+
+	_, err = d.git(ctx, dir, "checkout", "--quiet", "--force", "-B", d.opts.Branch, "FETCH_HEAD")
+	return err
+}
+
+// update applies change to a checkout of the branch and pushes the result,
+// retrying on top of the new branch tip when the push is rejected. change
+// reports whether it modified the work tree.
+func (d *gitDestination) update(ctx context.Context, key string, message string, change func(dir string) (bool, error)) (diag diag.Diagnostics) {
+	dir, err := d.checkout(ctx)
+	if err != nil {
+		diag.AddError("git", fmt.Sprintf("failed to checkout %s: %s", d.opts.URL, err))
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	for attempt := 0; ; attempt++ {
+		changed, err := change(dir)
+		if err != nil {
+			diag.AddError("git", fmt.Sprintf("failed to update %s: %s", key, err))
+			return
+		}
+
+		if changed {
+			err = d.commitAndPush(ctx, dir, key, message)
+		}
+
+		var gitErr *gitError
+		if err != nil && errors.As(err, &gitErr) && isGitPushRejected(gitErr) && attempt < d.opts.PushRetries {
+			if err := d.reset(ctx, dir); err != nil {
+				diag.AddError("git", fmt.Sprintf("failed to fetch %s: %s", d.opts.URL, err))
+				return
+			}
+			continue
+		}
+		if err != nil {
+			diag.AddError("git", fmt.Sprintf("failed to push %s: %s", key, err))
+			return
+		}
+
+		break
+	}
+
+	d.commit, err = d.lastCommit(ctx, dir, key)
+	if err != nil {
+		diag.AddError("git", fmt.Sprintf("failed to read log of %s: %s", key, err))
+		return
+	}
+
+	return
+}
+
+func (d *gitDestination) commitAndPush(ctx context.Context, dir string, key string, message string) error {
+	if _, err := d.git(ctx, dir, "add", "--all", "--", key); err != nil {
+		return err
+	}
+
+	args := []string{"commit", "--quiet", "--message", message}
+	if d.opts.SigningKey != "" {
+		args = append([]string{"-c", "gpg.format=" + d.opts.SigningFormat, "-c", "user.signingKey=" + d.opts.SigningKey}, args...)
+		args = append(args, "--gpg-sign")
+	}
+	if _, err := d.git(ctx, dir, args...); err != nil {
+		return err
+	}
+
+	_, err := d.git(ctx, dir, "push", "--quiet", "origin", "HEAD:refs/heads/"+d.opts.Branch)
+	return err
+}
+
+// lastCommit returns the last commit touching key. The log of a shallow
+// clone attributes every file to the oldest commit fetched, so the history
+// is deepened until the commit found is not a shallow boundary.
+func (d *gitDestination) lastCommit(ctx context.Context, dir string, key string) (string, error) {
+	// The branch has no commits yet.
+	if _, err := d.git(ctx, dir, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return "", nil
+	}
+
+	for depth := gitDeepenStep; ; depth *= 2 {
+		commit, err := d.git(ctx, dir, "log", "-1", "--format=%H", "--", key)
+		if err != nil || commit == "" {
+			return commit, err
+		}
+
+		boundaries, err := d.shallowBoundaries(ctx, dir)
+		if err != nil {
+			return "", err
+		}
+		if !boundaries[commit] {
+			return commit, nil
+		}
+
+		if _, err := d.git(ctx, dir, "fetch", "--quiet", "--deepen", strconv.Itoa(depth), "origin", "refs/heads/"+d.opts.Branch); err != nil {
+			return "", err
+		}
+	}
+}
+
+// shallowBoundaries returns the commits whose parents were not fetched, or
+// nothing once the clone has the whole history.
+func (d *gitDestination) shallowBoundaries(ctx context.Context, dir string) (map[string]bool, error) {
+	path, err := d.git(ctx, dir, "rev-parse", "--git-path", "shallow")
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := os.ReadFile(filepath.Join(dir, path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	boundaries := make(map[string]bool)
+	for _, commit := range strings.Fields(string(contents)) {
+		boundaries[commit] = true
+	}
+
+	return boundaries, nil
+}
+
+func (d *gitDestination) put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) (diag diag.Diagnostics) {
+	if problem := gitPathProblem(key); problem != "" {
+		diag.AddError("git", fmt.Sprintf("path %s %s", key, problem))
+		return
+	}
+
+	return d.update(ctx, key, gitCommitMessage(key, metadata), func(dir string) (bool, error) {
+		if existing, err := readWorkTreeFile(dir, key); err == nil && bytes.Equal(existing, contents) {
+			return false, nil
+		}
+
+		if err := writeWorkTreeFile(dir, key, contents); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+}
+
+func (d *gitDestination) get(ctx context.Context, key string) (contents []byte, diag diag.Diagnostics) {
+	if problem := gitPathProblem(key); problem != "" {
+		diag.AddError("git", fmt.Sprintf("path %s %s", key, problem))
+		return
+	}
+
+	dir, err := d.checkout(ctx)
+	if err != nil {
+		diag.AddError("git", fmt.Sprintf("failed to checkout %s: %s", d.opts.URL, err))
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	contents, err = readWorkTreeFile(dir, key)
+	if errors.Is(err, fs.ErrNotExist) {
+		d.commit = ""
+		return nil, diag
+	}
+	if err != nil {
+		diag.AddError("git", fmt.Sprintf("failed to read %s: %s", key, err))
+		return
+	}
+
+	d.commit, err = d.lastCommit(ctx, dir, key)
+	if err != nil {
+		diag.AddError("git", fmt.Sprintf("failed to read log of %s: %s", key, err))
+		return
+	}
+
+	return
+}
+
+func (d *gitDestination) head(ctx context.Context, key string) (obj *destinationObject, diag diag.Diagnostics) {
+	contents, diags := d.get(ctx, key)
+	diag.Append(diags...)
+	if diag.HasError() || contents == nil {
+		return
+	}
+
+	obj = &destinationObject{
+		Size:   int64(len(contents)),
+		Sha256: sha256Hex(contents),
+	}
+
+	return
+}
+
+func (d *gitDestination) delete(ctx context.Context, key string) (diag diag.Diagnostics) {
+	if problem := gitPathProblem(key); problem != "" {
+		diag.AddError("git", fmt.Sprintf("path %s %s", key, problem))
+		return
+	}
+
+	return d.update(ctx, key, fmt.Sprintf("Remove %s", key), func(dir string) (bool, error) {
+		err := removeWorkTreeFile(dir, key)
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		return err == nil, err
+	})
+}
+
+func (d *gitDestination) list(ctx context.Context, prefix string) (keys []string, diag diag.Diagnostics) {
+	dir, err := d.checkout(ctx)
+	if err != nil {
+		diag.AddError("git", fmt.Sprintf("failed to checkout %s: %s", d.opts.URL, err))
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := d.git(ctx, dir, "ls-files")
+	if err != nil {
+		diag.AddError("git", fmt.Sprintf("failed to list files: %s", err))
+		return
+	}
+
+	for _, key := range strings.Split(files, "\n") {
+		if key != "" && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	return
+}
+
+// readWorkTreeFile reads key from the work tree at dir. The work tree
+// functions access dir through an os.Root, so that neither ".." nor symlinks
+// committed to the repository lead outside of it.
+func readWorkTreeFile(dir string, key string) ([]byte, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	f, err := root.Open(filepath.FromSlash(key))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// writeWorkTreeFile writes contents to key in the work tree at dir, creating
+// its parent directories.
+func writeWorkTreeFile(dir string, key string, contents []byte) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	segments := strings.Split(key, "/")
+	for i := 1; i < len(segments); i++ {
+		if err := root.Mkdir(filepath.Join(segments[:i]...), 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+
+	f, err := root.OpenFile(filepath.FromSlash(key), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// removeWorkTreeFile removes key from the work tree at dir.
+func removeWorkTreeFile(dir string, key string) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	return root.Remove(filepath.FromSlash(key))
+}
+
+// gitCommitMessage describes a state sync in the commit log, with the
+// workspace, serial and run as trailers so they can be searched with
+// `git log --grep`.
+func gitCommitMessage(key string, metadata destinationMetadata) string {
+	subject := fmt.Sprintf("Sync %s", key)
+	if metadata.WorkspaceName != "" {
+		subject = fmt.Sprintf("Sync state of %s, serial %d", metadata.WorkspaceName, metadata.Serial)
+	}
+
+	var trailers []string
+	if metadata.WorkspaceId != "" {
+		trailers = append(trailers, "Workspace-Id: "+metadata.WorkspaceId)
+	}
+	if metadata.WorkspaceName != "" {
+		trailers = append(trailers, "Workspace-Name: "+metadata.WorkspaceName)
+		trailers = append(trailers, fmt.Sprintf("Serial: %d", metadata.Serial))
+	}
+	if metadata.RunId != "" {
+		trailers = append(trailers, "Run-Id: "+metadata.RunId)
+	}
+
+	if len(trailers) == 0 {
+		return subject
+	}
+
+	return subject + "\n\n" + strings.Join(trailers, "\n")
+}
+
+// isGitPushRejected reports whether a push failed because the remote branch
+// moved on since it was fetched.
+func isGitPushRejected(err *gitError) bool {
+	return strings.Contains(err.stderr, "non-fast-forward") ||
+		strings.Contains(err.stderr, "fetch first") ||
+		strings.Contains(err.stderr, "[rejected]")
+}
@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitPathProblem(t *testing.T) {
+	for path, valid := range map[string]bool{
+		"terraform.tfstate":                  true,
+		"{workspace_name}/terraform.tfstate": true,
+		"a/b/c.tfstate":                      true,
+		"a..b/.c":                            true,
+		".github/state":                      true,
+		"":                                   false,
+		"/terraform.tfstate":                 false,
+		"./terraform.tfstate":                false,
+		"../terraform.tfstate":               false,
+		"a/../b":                             false,
+		"a/../../x":                          false,
+		"a/./b":                              false,
+		"a/..":                               false,
+		".git/hooks/post-commit":             false,
+		".GIT/config":                        false,
+	} {
+		if problem := gitPathProblem(path); (problem == "") != valid {
+			t.Errorf("%q: got problem %q, want valid %t", path, problem, valid)
+		}
+	}
+}
+
+// newTestGitRepository returns the url of a new bare repository with a
+// commit on main, created by running commands in a work tree.
+func newTestGitRepository(t *testing.T, commands ...[]string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	bare := filepath.Join(t.TempDir(), "repository.git")
+	work := t.TempDir()
+
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@localhost", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@localhost")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%q: %s: %s", args, err, out)
+		}
+	}
+
+	run(work, "git", "init", "--quiet", "--bare", bare)
+	run(work, "git", "init", "--quiet", "--initial-branch", "main")
+	run(work, "git", "commit", "--quiet", "--allow-empty", "--message", "init")
+	for _, command := range commands {
+		run(work, command...)
+	}
+	run(work, "git", "push", "--quiet", "file://"+bare, "HEAD:refs/heads/main")
+
+	return "file://" + bare
+}
+
+func TestGitDestination(t *testing.T) {
+	dst := newGitDestination(gitOptions{URL: newTestGitRepository(t)})
+	ctx := context.Background()
+
+	state := []byte(`{"serial":1}`)
+	if diags := dst.put(ctx, "envs/prod/terraform.tfstate", state, destinationMetadata{WorkspaceId: "ws-abc"}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	commit := dst.commit
+
+	contents, diags := dst.get(ctx, "envs/prod/terraform.tfstate")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if string(contents) != string(state) || dst.commit != commit || commit == "" {
+		t.Errorf("got %q at %q, want %q at %q", contents, dst.commit, state, commit)
+	}
+
+	if diags := dst.delete(ctx, "envs/prod/terraform.tfstate"); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	contents, diags = dst.get(ctx, "envs/prod/terraform.tfstate")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if contents != nil {
+		t.Errorf("got %q after delete, want nothing", contents)
+	}
+}
+
+func TestGitDestinationEscape(t *testing.T) {
+	outside := t.TempDir()
+	dst := newGitDestination(gitOptions{URL: newTestGitRepository(t,
+		[]string{"ln", "-s", outside, "link"},
+		[]string{"git", "add", "link"},
+		[]string{"git", "commit", "--quiet", "--message", "link"},
+	)})
+	ctx := context.Background()
+
+	for _, key := range []string{"../escaped", "a/../../escaped", ".git/escaped", "link/escaped"} {
+		if diags := dst.put(ctx, key, []byte(`{"serial":1}`), destinationMetadata{}); !diags.HasError() {
+			t.Errorf("%s: got no error", key)
+		}
+		if _, diags := dst.get(ctx, key); !diags.HasError() {
+			t.Errorf("%s: got no error reading", key)
+		}
+	}
+
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d files written outside of the repository", len(entries))
+	}
+}
+
+func TestGitDestinationLastCommit(t *testing.T) {
+	url := newTestGitRepository(t)
+	dst := newGitDestination(gitOptions{URL: url})
+	other := newGitDestination(gitOptions{URL: url})
+	ctx := context.Background()
+
+	if diags := dst.put(ctx, "a/terraform.tfstate", []byte(`{"serial":1}`), destinationMetadata{}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	commit := dst.commit
+
+	// More commits than the first deepening fetches.
+	for serial := range 2 * gitDeepenStep {
+		if diags := other.put(ctx, "b/terraform.tfstate", fmt.Appendf(nil, `{"serial":%d}`, serial), destinationMetadata{}); diags.HasError() {
+			t.Fatal(diags)
+		}
+	}
+
+	if _, diags := dst.get(ctx, "a/terraform.tfstate"); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if dst.commit != commit {
+		t.Errorf("got commit %q, want %q of the put rather than the branch tip %q", dst.commit, commit, other.commit)
+	}
+}
+
+func TestGitDestinationPushRejected(t *testing.T) {
+	url := newTestGitRepository(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		retries int
+		wantErr bool
+	}{
+		{retries: 1},
+		{retries: 0, wantErr: true},
+	} {
+		dst := newGitDestination(gitOptions{URL: url, PushRetries: tc.retries})
+		other := newGitDestination(gitOptions{URL: url})
+		key := fmt.Sprintf("retries-%d/terraform.tfstate", tc.retries)
+		competing := fmt.Sprintf("retries-%d/other.tfstate", tc.retries)
+
+		// A second writer pushes between the checkout and the push.
+		attempts := 0
+		diags := dst.update(ctx, key, "put", func(dir string) (bool, error) {
+			attempts++
+			if attempts == 1 {
+				if diags := other.put(ctx, competing, []byte(`{"serial":1}`), destinationMetadata{}); diags.HasError() {
+					return false, fmt.Errorf("%v", diags)
+				}
+			}
+			return true, writeWorkTreeFile(dir, key, []byte(`{"serial":2}`))
+		})
+
+		if tc.wantErr {
+			if !diags.HasError() {
+				t.Errorf("retries %d: got no error for a rejected push", tc.retries)
+			}
+			continue
+		}
+		if diags.HasError() {
+			t.Fatalf("retries %d: %v", tc.retries, diags)
+		}
+		if attempts != 2 {
+			t.Errorf("retries %d: got %d attempts, want 2", tc.retries, attempts)
+		}
+
+		// The put landed on top of the competing commit.
+		for _, path := range []string{key, competing} {
+			if contents, diags := dst.get(ctx, path); diags.HasError() || contents == nil {
+				t.Errorf("retries %d: got %q (%v) for %s, want it on the branch", tc.retries, contents, diags, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+var _ destination = &httpDestination{}
+
+const (
+	// httpHeaderWorkspaceId records which workspace a state was synced from.
+	httpHeaderWorkspaceId = "X-Tfsync-Workspace-Id"
+
+	// httpHeaderChecksumSha256 carries the hex sha256 sum of the contents, as
+	// understood by e.g. Artifactory and Nexus.
+	httpHeaderChecksumSha256 = "X-Checksum-Sha256"
+)
+
+// httpDestination stores state on a server accepting PUT, GET, HEAD and
+// DELETE requests, e.g. a WebDAV share or an artifact repository. Keys are
+// appended to the base url.
+type httpDestination struct {
+	client  *http.Client
+	baseURL string
+	headers map[string]string
+}
+
+func newHTTPDestination(baseURL string, headers map[string]string, maxRetries int) *httpDestination {
+	client := cleanhttp.DefaultPooledClient()
+	client.Transport = &retryTransport{base: client.Transport, maxRetries: maxRetries}
+
+	return &httpDestination{client: client, baseURL: baseURL, headers: headers}
+}
+
+func (d *httpDestination) url(key string) string {
+	return httpObjectURL(d.baseURL, key)
+}
+
+// httpObjectURL returns the url of key below baseURL.
+func httpObjectURL(baseURL string, key string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if key == "" {
+		return baseURL
+	}
+	return baseURL + "/" + strings.TrimPrefix(key, "/")
+}
+
+func (d *httpDestination) do(ctx context.Context, method string, key string, body []byte, header http.Header) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, d.url(key), r)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range d.headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	return d.client.Do(req)
+}
+
+func (d *httpDestination) put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) (diag diag.Diagnostics) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set(httpHeaderChecksumSha256, sha256Hex(contents))
+	if metadata.WorkspaceId != "" {
+		header.Set(httpHeaderWorkspaceId, metadata.WorkspaceId)
+	}
+
+	resp, err := d.do(ctx, http.MethodPut, key, contents, header)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to put %s: %s", d.url(key), err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		diag.AddError("http client", fmt.Sprintf("failed to put %s: %s", d.url(key), resp.Status))
+		return
+	}
+
+	return
+}
+
+func (d *httpDestination) get(ctx context.Context, key string) (contents []byte, diag diag.Diagnostics) {
+	resp, err := d.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to get %s: %s", d.url(key), err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		diag.AddError("http client", fmt.Sprintf("failed to get %s: %s", d.url(key), resp.Status))
+		return
+	}
+
+	contents, err = io.ReadAll(resp.Body)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to read body: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *httpDestination) head(ctx context.Context, key string) (obj *destinationObject, diag diag.Diagnostics) {
+	resp, err := d.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to head %s: %s", d.url(key), err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		diag.AddError("http client", fmt.Sprintf("failed to head %s: %s", d.url(key), resp.Status))
+		return
+	}
+
+	obj = &destinationObject{
+		Size:        resp.ContentLength,
+		Sha256:      strings.ToLower(resp.Header.Get(httpHeaderChecksumSha256)),
+		WorkspaceId: resp.Header.Get(httpHeaderWorkspaceId),
+	}
+
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.LastModified = t.In(time.UTC)
+	}
+
+	return
+}
+
+func (d *httpDestination) delete(ctx context.Context, key string) (diag diag.Diagnostics) {
+	resp, err := d.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to delete %s: %s", d.url(key), err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		diag.AddError("http client", fmt.Sprintf("failed to delete %s: %s", d.url(key), resp.Status))
+		return
+	}
+
+	return
+}
+
+func (d *httpDestination) list(ctx context.Context, prefix string) (keys []string, diag diag.Diagnostics) {
+	diag.AddError("http client", "listing is not supported by http destinations")
+	return
+}
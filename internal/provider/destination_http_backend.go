@@ -0,0 +1,328 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+var _ destination = &httpBackendDestination{}
+
+const (
+	defaultHTTPBackendUpdateMethod = http.MethodPost
+	defaultHTTPBackendLockMethod   = "LOCK"
+	defaultHTTPBackendUnlockMethod = "UNLOCK"
+)
+
+// httpBackendOptions configures an httpBackendDestination. It mirrors the
+// settings of terraform's http backend.
+type httpBackendOptions struct {
+	UpdateMethod  string
+	LockAddress   string
+	LockMethod    string
+	UnlockAddress string
+	UnlockMethod  string
+	Username      string
+	Password      string
+
+	SkipCertVerification   bool
+	ClientCertificatePem   string
+	ClientPrivateKeyPem    string
+	ClientCaCertificatePem string
+}
+
+// httpBackendDestination stores state on a server speaking terraform's http
+// backend protocol, e.g. GitLab-managed terraform state. Keys are state
+// addresses. When a lock address is configured, the state is locked while
+// it is written.
+type httpBackendDestination struct {
+	client *http.Client
+	opts   httpBackendOptions
+}
+
+// httpBackendLockInfo is the lock payload terraform sends to the lock and
+// unlock addresses.
+type httpBackendLockInfo struct {
+	ID        string
+	Operation string
+	Info      string
+	Who       string
+	Version   string
+	Created   time.Time
+	Path      string
+}
+
+func newHTTPBackendDestination(opts httpBackendOptions, maxRetries int) (dst *httpBackendDestination, diag diag.Diagnostics) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: opts.SkipCertVerification,
+	}
+
+	if opts.ClientCaCertificatePem != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(opts.ClientCaCertificatePem)) {
+			diag.AddError("http client", "failed to parse client_ca_certificate_pem")
+			return
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.ClientCertificatePem != "" || opts.ClientPrivateKeyPem != "" {
+		cert, err := tls.X509KeyPair([]byte(opts.ClientCertificatePem), []byte(opts.ClientPrivateKeyPem))
+		if err != nil {
+			diag.AddError("http client", fmt.Sprintf("failed to load client certificate: %s", err))
+			return
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := cleanhttp.DefaultPooledTransport()
+	transport.TLSClientConfig = tlsConfig
+
+	if opts.UpdateMethod == "" {
+		opts.UpdateMethod = defaultHTTPBackendUpdateMethod
+	}
+	if opts.LockMethod == "" {
+		opts.LockMethod = defaultHTTPBackendLockMethod
+	}
+	if opts.UnlockMethod == "" {
+		opts.UnlockMethod = defaultHTTPBackendUnlockMethod
+	}
+
+	dst = &httpBackendDestination{
+		client: &http.Client{Transport: &retryTransport{base: transport, maxRetries: maxRetries}},
+		opts:   opts,
+	}
+
+	return
+}
+
+func (d *httpBackendDestination) do(ctx context.Context, method string, address string, body []byte, header http.Header) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, address, r)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if d.opts.Username != "" {
+		req.SetBasicAuth(d.opts.Username, d.opts.Password)
+	}
+
+	return d.client.Do(req)
+}
+
+func (d *httpBackendDestination) put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) (diag diag.Diagnostics) {
+	address := key
+
+	if d.opts.LockAddress != "" {
+		lock, diags := d.lock(ctx, key, metadata)
+		diag.Append(diags...)
+		if diag.HasError() {
+			return
+		}
+		defer func() {
+			diag.Append(d.unlock(context.WithoutCancel(ctx), lock)...)
+		}()
+
+		u, err := url.Parse(key)
+		if err != nil {
+			diag.AddError("http client", fmt.Sprintf("failed to parse address: %s", err))
+			return
+		}
+		q := u.Query()
+		q.Set("ID", lock.ID)
+		u.RawQuery = q.Encode()
+		address = u.String()
+	}
+
+	hash := md5.Sum(contents)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(hash[:]))
+
+	resp, err := d.do(ctx, d.opts.UpdateMethod, address, contents, header)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to put %s: %s", key, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		diag.AddError("http client", fmt.Sprintf("failed to put %s: %s", key, resp.Status))
+		return
+	}
+
+	return
+}
+
+func (d *httpBackendDestination) get(ctx context.Context, key string) (contents []byte, diag diag.Diagnostics) {
+	resp, err := d.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to get %s: %s", key, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent:
+		return
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		diag.AddError("http client", fmt.Sprintf("failed to get %s: %s", key, resp.Status))
+		return
+	}
+
+	contents, err = io.ReadAll(resp.Body)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to read body: %s", err))
+		return
+	}
+
+	// terraform treats an empty body as no state.
+	if len(contents) == 0 {
+		contents = nil
+	}
+
+	return
+}
+
+// head reads the whole state, as the protocol has no way to read metadata
+// alone.
+func (d *httpBackendDestination) head(ctx context.Context, key string) (obj *destinationObject, diag diag.Diagnostics) {
+	contents, diags := d.get(ctx, key)
+	diag.Append(diags...)
+	if diag.HasError() || contents == nil {
+		return
+	}
+
+	obj = &destinationObject{
+		Size:   int64(len(contents)),
+		Sha256: sha256Hex(contents),
+	}
+
+	return
+}
+
+func (d *httpBackendDestination) delete(ctx context.Context, key string) (diag diag.Diagnostics) {
+	resp, err := d.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to delete %s: %s", key, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		diag.AddError("http client", fmt.Sprintf("failed to delete %s: %s", key, resp.Status))
+		return
+	}
+
+	return
+}
+
+func (d *httpBackendDestination) list(ctx context.Context, prefix string) (keys []string, diag diag.Diagnostics) {
+	diag.AddError("http client", "listing is not supported by http backend destinations")
+	return
+}
+
+func (d *httpBackendDestination) lock(ctx context.Context, key string, metadata destinationMetadata) (lock *httpBackendLockInfo, diag diag.Diagnostics) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to generate lock id: %s", err))
+		return
+	}
+
+	who, _ := os.Hostname()
+
+	lock = &httpBackendLockInfo{
+		ID:        fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]),
+		Operation: "tfsync",
+		Info:      fmt.Sprintf("syncing state of workspace %s", metadata.WorkspaceId),
+		Who:       who,
+		Created:   time.Now().UTC(),
+		Path:      key,
+	}
+
+	body, err := json.Marshal(lock)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to encode lock: %s", err))
+		return
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	resp, err := d.do(ctx, d.opts.LockMethod, d.opts.LockAddress, body, header)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to lock %s: %s", d.opts.LockAddress, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusLocked:
+		holder, _ := io.ReadAll(resp.Body)
+		diag.AddError("http client", fmt.Sprintf("state at %s is locked: %s", d.opts.LockAddress, holder))
+		return
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		diag.AddError("http client", fmt.Sprintf("failed to lock %s: %s", d.opts.LockAddress, resp.Status))
+		return
+	}
+
+	return
+}
+
+func (d *httpBackendDestination) unlock(ctx context.Context, lock *httpBackendLockInfo) (diag diag.Diagnostics) {
+	address := d.opts.UnlockAddress
+	if address == "" {
+		address = d.opts.LockAddress
+	}
+
+	body, err := json.Marshal(lock)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to encode lock: %s", err))
+		return
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	resp, err := d.do(ctx, d.opts.UnlockMethod, address, body, header)
+	if err != nil {
+		diag.AddError("http client", fmt.Sprintf("failed to unlock %s: %s", address, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		diag.AddError("http client", fmt.Sprintf("failed to unlock %s: %s", address, resp.Status))
+		return
+	}
+
+	return
+}
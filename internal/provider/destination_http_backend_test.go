@@ -0,0 +1,208 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeHTTPBackend is a server speaking terraform's http backend protocol. It
+// stores the state at /state, locks it at /lock and records every request
+// as "METHOD path".
+type fakeHTTPBackend struct {
+	mu       sync.Mutex
+	requests []string
+	state    []byte
+	lockId   string
+
+	// updateStatus, if set, is returned to updates of the state.
+	updateStatus int
+}
+
+func (f *fakeHTTPBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if user, password, _ := r.BasicAuth(); user != "user" || password != "password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "LOCK /lock":
+		var lock httpBackendLockInfo
+		if err := json.Unmarshal(body, &lock); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if f.lockId != "" {
+			w.WriteHeader(http.StatusLocked)
+			_, _ = w.Write([]byte(f.lockId))
+			return
+		}
+		f.lockId = lock.ID
+
+	case "UNLOCK /lock":
+		var lock httpBackendLockInfo
+		if err := json.Unmarshal(body, &lock); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if lock.ID != f.lockId {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.lockId = ""
+
+	case "POST /state":
+		if f.lockId != "" && r.URL.Query().Get("ID") != f.lockId {
+			w.WriteHeader(http.StatusLocked)
+			return
+		}
+		if f.updateStatus != 0 {
+			w.WriteHeader(f.updateStatus)
+			return
+		}
+		f.state = body
+
+	case "GET /state":
+		if f.state == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(f.state)
+
+	case "DELETE /state":
+		f.state = nil
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeHTTPBackend) takeRequests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	requests := f.requests
+	f.requests = nil
+	return requests
+}
+
+func newFakeHTTPBackend(t *testing.T, f *fakeHTTPBackend, locking bool) (*httpBackendDestination, string) {
+	t.Helper()
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	opts := httpBackendOptions{Username: "user", Password: "password"}
+	if locking {
+		opts.LockAddress = srv.URL + "/lock"
+	}
+
+	dst, diags := newHTTPBackendDestination(opts, 0)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	return dst, srv.URL + "/state"
+}
+
+func TestHTTPBackendDestinationLocking(t *testing.T) {
+	f := &fakeHTTPBackend{}
+	dst, address := newFakeHTTPBackend(t, f, true)
+	ctx := context.Background()
+
+	if diags := dst.put(ctx, address, []byte(`{"serial":1}`), destinationMetadata{WorkspaceId: "ws-abc"}); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if got, want := f.takeRequests(), []string{"LOCK /lock", "POST /state", "UNLOCK /lock"}; !slices.Equal(got, want) {
+		t.Errorf("got requests %q, want %q", got, want)
+	}
+	if f.lockId != "" {
+		t.Errorf("state is still locked by %s", f.lockId)
+	}
+
+	contents, diags := dst.get(ctx, address)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if string(contents) != `{"serial":1}` {
+		t.Errorf("got %q, want %q", contents, `{"serial":1}`)
+	}
+
+	if diags := dst.delete(ctx, address); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	contents, diags = dst.get(ctx, address)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if contents != nil {
+		t.Errorf("got %q after delete, want no state", contents)
+	}
+}
+
+func TestHTTPBackendDestinationWithoutLocking(t *testing.T) {
+	f := &fakeHTTPBackend{}
+	dst, address := newFakeHTTPBackend(t, f, false)
+
+	if diags := dst.put(context.Background(), address, []byte(`{"serial":1}`), destinationMetadata{}); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if got, want := f.takeRequests(), []string{"POST /state"}; !slices.Equal(got, want) {
+		t.Errorf("got requests %q, want %q", got, want)
+	}
+}
+
+func TestHTTPBackendDestinationLocked(t *testing.T) {
+	f := &fakeHTTPBackend{lockId: "other"}
+	dst, address := newFakeHTTPBackend(t, f, true)
+
+	if diags := dst.put(context.Background(), address, []byte(`{"serial":1}`), destinationMetadata{}); !diags.HasError() {
+		t.Fatal("got no error writing locked state")
+	}
+
+	// Neither is the state written nor the other lock released.
+	if got, want := f.takeRequests(), []string{"LOCK /lock"}; !slices.Equal(got, want) {
+		t.Errorf("got requests %q, want %q", got, want)
+	}
+	if f.state != nil || f.lockId != "other" {
+		t.Errorf("got state %q and lock %q, want no state and lock %q", f.state, f.lockId, "other")
+	}
+}
+
+func TestHTTPBackendDestinationUnlockOnFailure(t *testing.T) {
+	f := &fakeHTTPBackend{updateStatus: http.StatusInternalServerError}
+	dst, address := newFakeHTTPBackend(t, f, true)
+
+	if diags := dst.put(context.Background(), address, []byte(`{"serial":1}`), destinationMetadata{}); !diags.HasError() {
+		t.Fatal("got no error for a failed update")
+	}
+
+	if got, want := f.takeRequests(), []string{"LOCK /lock", "POST /state", "UNLOCK /lock"}; !slices.Equal(got, want) {
+		t.Errorf("got requests %q, want %q", got, want)
+	}
+	if f.lockId != "" {
+		t.Errorf("state is still locked by %s", f.lockId)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// fakeHTTPObjects is a server storing objects by path, like a WebDAV share.
+// It requires the header "Authorization: token" and records the checksum
+// header of each upload.
+type fakeHTTPObjects struct {
+	mu        sync.Mutex
+	objects   map[string][]byte
+	checksums map[string]string
+}
+
+func (f *fakeHTTPObjects) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get(httpHeaderChecksumSha256) != sha256Hex(body) {
+			http.Error(w, "checksum mismatch", http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+		f.checksums[r.URL.Path] = r.Header.Get(httpHeaderChecksumSha256)
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(httpHeaderChecksumSha256, f.checksums[r.URL.Path])
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(body)
+		}
+
+	case http.MethodDelete:
+		if _, ok := f.objects[r.URL.Path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newFakeHTTPObjects(t *testing.T) (*fakeHTTPObjects, string) {
+	t.Helper()
+
+	f := &fakeHTTPObjects{objects: make(map[string][]byte), checksums: make(map[string]string)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	return f, srv.URL
+}
+
+func TestHTTPDestination(t *testing.T) {
+	f, url := newFakeHTTPObjects(t)
+	dst := newHTTPDestination(url+"/repository/", map[string]string{"Authorization": "token"}, 0)
+	ctx := context.Background()
+
+	state := []byte(`{"serial":3}`)
+	if diags := dst.put(ctx, "network/terraform.tfstate", state, destinationMetadata{}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if _, ok := f.objects["/repository/network/terraform.tfstate"]; !ok {
+		t.Fatalf("got objects %q, want /repository/network/terraform.tfstate", f.objects)
+	}
+
+	obj, diags := dst.head(ctx, "network/terraform.tfstate")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if obj == nil || obj.Sha256 != sha256Hex(state) || obj.Size != int64(len(state)) {
+		t.Errorf("got %+v, want the checksum and size of the state", obj)
+	}
+
+	contents, diags := dst.get(ctx, "network/terraform.tfstate")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if !bytes.Equal(contents, state) {
+		t.Errorf("got %q, want %q", contents, state)
+	}
+
+	if diags := dst.delete(ctx, "network/terraform.tfstate"); diags.HasError() {
+		t.Fatal(diags)
+	}
+	// Deleting a missing object is not an error.
+	if diags := dst.delete(ctx, "network/terraform.tfstate"); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if contents, diags := dst.get(ctx, "network/terraform.tfstate"); diags.HasError() || contents != nil {
+		t.Errorf("got %q (%v) after delete, want nothing", contents, diags)
+	}
+
+	unauthorized := newHTTPDestination(url+"/repository", nil, 0)
+	if diags := unauthorized.put(ctx, "network/terraform.tfstate", state, destinationMetadata{}); !diags.HasError() {
+		t.Error("got no error without the Authorization header")
+	}
+}
+
+func TestHTTPObjectResourceSync(t *testing.T) {
+	f, url := newFakeHTTPObjects(t)
+
+	r := &HTTPObjectResource{}
+	r.tfeClient = newFakeTfeClient(t)
+	r.stateCache = newStateCache(1<<20, "")
+
+	ctx := context.Background()
+	data := &HTTPObjectResourceModel{
+		destinationResourceModel: destinationResourceModel{
+			WorkspaceId: types.StringValue("ws-abc"),
+		},
+		URL:           types.StringValue(url),
+		Key:           types.StringValue("network/terraform.tfstate.gz"),
+		Headers:       types.MapValueMust(types.StringType, map[string]attr.Value{"Authorization": types.StringValue("token")}),
+		Compression:   types.StringValue(stateCompressionGzip),
+		EncryptionKey: types.StringNull(),
+	}
+
+	if diags := r.sync(ctx, data); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	want := sha256Contents([]byte(fakeTfeState))
+	if data.Id.ValueString() != "ws-abc/"+url+"/network/terraform.tfstate.gz" {
+		t.Errorf("got id %s", data.Id)
+	}
+	if !data.StateContentsSha256.Equal(want) || !data.ObjectContentsSha256.Equal(want) {
+		t.Errorf("got checksums %s and %s, want %s", data.StateContentsSha256, data.ObjectContentsSha256, want)
+	}
+	if stored := f.objects["/network/terraform.tfstate.gz"]; stored == nil || string(stored) == fakeTfeState {
+		t.Errorf("got stored contents %q, want them compressed", stored)
+	}
+
+	dst, diags := data.destination(ctx, 0)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	data.clear()
+	found, diags := readDestination(ctx, data, dst, []byte(fakeTfeState))
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if !found || !data.ObjectContentsSha256.Equal(want) {
+		t.Errorf("got found %t and checksum %s, want %s", found, data.ObjectContentsSha256, want)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+var _ destination = &localDestination{}
+
+// localDestination stores state as files on a local or mounted filesystem.
+// Keys are file paths. Files are written to a temporary file and renamed
+// into place, so readers never see a partial state.
+type localDestination struct {
+	mode fs.FileMode
+}
+
+func newLocalDestination(mode fs.FileMode) *localDestination {
+	return &localDestination{mode: mode}
+}
+
+func (d *localDestination) put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) (diag diag.Diagnostics) {
+	dir := filepath.Dir(key)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		diag.AddError("local file", fmt.Sprintf("failed to create directory: %s", err))
+		return
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(key)+".*")
+	if err != nil {
+		diag.AddError("local file", fmt.Sprintf("failed to create temporary file: %s", err))
+		return
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		diag.AddError("local file", fmt.Sprintf("failed to write file: %s", err))
+		return
+	}
+
+	if err := f.Chmod(d.mode); err != nil {
+		f.Close()
+		diag.AddError("local file", fmt.Sprintf("failed to set file permission: %s", err))
+		return
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		diag.AddError("local file", fmt.Sprintf("failed to sync file: %s", err))
+		return
+	}
+
+	if err := f.Close(); err != nil {
+		diag.AddError("local file", fmt.Sprintf("failed to close file: %s", err))
+		return
+	}
+
+	if err := os.Rename(f.Name(), key); err != nil {
+		diag.AddError("local file", fmt.Sprintf("failed to rename file: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *localDestination) get(ctx context.Context, key string) (contents []byte, diag diag.Diagnostics) {
+	contents, err := os.ReadFile(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, diag
+	}
+	if err != nil {
+		diag.AddError("local file", fmt.Sprintf("failed to read file: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *localDestination) head(ctx context.Context, key string) (obj *destinationObject, diag diag.Diagnostics) {
+	info, err := os.Stat(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		diag.AddError("local file", fmt.Sprintf("failed to stat file: %s", err))
+		return
+	}
+
+	contents, diags := d.get(ctx, key)
+	diag.Append(diags...)
+	if diag.HasError() || contents == nil {
+		return
+	}
+
+	obj = &destinationObject{
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+		Sha256:       sha256Hex(contents),
+	}
+
+	return
+}
+
+func (d *localDestination) delete(ctx context.Context, key string) (diag diag.Diagnostics) {
+	if err := os.Remove(key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		diag.AddError("local file", fmt.Sprintf("failed to remove file: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *localDestination) list(ctx context.Context, prefix string) (keys []string, diag diag.Diagnostics) {
+	root := filepath.Dir(prefix)
+	if strings.HasSuffix(prefix, string(filepath.Separator)) {
+		root = filepath.Clean(prefix)
+	}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() && strings.HasPrefix(path, prefix) {
+			keys = append(keys, path)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		diag.AddError("local file", fmt.Sprintf("failed to list files: %s", err))
+		return
+	}
+
+	return
+}
@@ -0,0 +1,602 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+var _ destination = &ociDestination{}
+
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociEmptyMediaType    = "application/vnd.oci.empty.v1+json"
+
+	// ociStateArtifactType is the artifact type of tfsync state manifests and
+	// the media type of their single layer.
+	ociStateArtifactType = "application/vnd.tfsync.state.v1+json"
+
+	ociAnnotationCreated       = "org.opencontainers.image.created"
+	ociAnnotationTitle         = "org.opencontainers.image.title"
+	ociAnnotationWorkspaceId   = "io.github.xorps.tfsync.workspace-id"
+	ociAnnotationWorkspaceName = "io.github.xorps.tfsync.workspace-name"
+	ociAnnotationSerial        = "io.github.xorps.tfsync.serial"
+	ociAnnotationLineage       = "io.github.xorps.tfsync.lineage"
+	ociAnnotationRunId         = "io.github.xorps.tfsync.run-id"
+
+	ociLatestTag = "latest"
+)
+
+// ociEmptyConfig is the empty json object used as the config of artifacts
+// without one, see the OCI image spec.
+var ociEmptyConfig = []byte("{}")
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Data        []byte            `json:"data,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ociOptions configures an ociDestination.
+type ociOptions struct {
+	// Repository is the registry host followed by the repository name, e.g.
+	// registry.example.com/backups/network.
+	Repository string
+	Username   string
+	Password   string
+
+	// Insecure talks plain http to the registry.
+	Insecure bool
+}
+
+// ociDestination stores state as an artifact in a repository of an
+// OCI-distribution registry. Keys are tags. Registries only delete
+// manifests by digest, so deleting a tag removes every tag of its manifest.
+type ociDestination struct {
+	client   *http.Client
+	registry string
+	name     string
+	opts     ociOptions
+
+	mu    sync.Mutex
+	token string
+}
+
+func newOCIDestination(opts ociOptions, maxRetries int) (dst *ociDestination, diag diag.Diagnostics) {
+	registry, name, ok := strings.Cut(opts.Repository, "/")
+	if !ok || registry == "" || name == "" {
+		diag.AddError("oci client", fmt.Sprintf("repository must be a registry host followed by a repository name, got: %s", opts.Repository))
+		return
+	}
+
+	client := cleanhttp.DefaultPooledClient()
+	client.Transport = &retryTransport{base: client.Transport, maxRetries: maxRetries}
+
+	dst = &ociDestination{client: client, registry: registry, name: name, opts: opts}
+	return
+}
+
+func (d *ociDestination) url(format string, args ...any) string {
+	scheme := "https"
+	if d.opts.Insecure {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/", scheme, d.registry, d.name) + fmt.Sprintf(format, args...)
+}
+
+// do sends a request, answering a bearer token challenge of the registry
+// once with the configured credentials.
+func (d *ociDestination) do(ctx context.Context, method string, rawURL string, body []byte, header http.Header) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, r)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		d.mu.Lock()
+		token := d.token
+		d.mu.Unlock()
+
+		switch {
+		case token != "":
+			req.Header.Set("Authorization", "Bearer "+token)
+		case d.opts.Username != "":
+			req.SetBasicAuth(d.opts.Username, d.opts.Password)
+		}
+
+		return d.client.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	scheme, params := parseOCIChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") {
+		return nil, fmt.Errorf("%s %s: unauthorized", method, rawURL)
+	}
+
+	if err := d.authenticate(ctx, params); err != nil {
+		return nil, err
+	}
+
+	return send()
+}
+
+// authenticate fetches a bearer token from the realm of a registry's
+// challenge.
+func (d *ociDestination) authenticate(ctx context.Context, params map[string]string) error {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" {
+		return fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+
+	q := realm.Query()
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull,push,delete", d.name)
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if d.opts.Username != "" {
+		req.SetBasicAuth(d.opts.Username, d.opts.Password)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get token from %s: %s", realm.Host, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode token: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.token = token.Token
+	if d.token == "" {
+		d.token = token.AccessToken
+	}
+
+	return nil
+}
+
+// parseOCIChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",service="registry"`.
+func parseOCIChallenge(challenge string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params = map[string]string{}
+
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+
+	return
+}
+
+// expectOCIStatus checks the status of resp, closing its body on failure.
+func expectOCIStatus(resp *http.Response, what string, statuses ...int) error {
+	for _, status := range statuses {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	return fmt.Errorf("failed to %s: %s %s", what, resp.Status, strings.TrimSpace(string(body)))
+}
+
+func (d *ociDestination) pushBlob(ctx context.Context, contents []byte) (desc ociDescriptor, err error) {
+	desc = ociDescriptor{Digest: "sha256:" + sha256Hex(contents), Size: int64(len(contents))}
+
+	resp, err := d.do(ctx, http.MethodHead, d.url("blobs/%s", desc.Digest), nil, nil)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return
+	}
+
+	resp, err = d.do(ctx, http.MethodPost, d.url("blobs/uploads/"), nil, nil)
+	if err != nil {
+		return
+	}
+	if err = expectOCIStatus(resp, "start blob upload", http.StatusAccepted); err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		err = fmt.Errorf("failed to start blob upload: %w", err)
+		return
+	}
+	q := location.Query()
+	q.Set("digest", desc.Digest)
+	location.RawQuery = q.Encode()
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+
+	resp, err = d.do(ctx, http.MethodPut, location.String(), contents, header)
+	if err != nil {
+		return
+	}
+	if err = expectOCIStatus(resp, "upload blob", http.StatusCreated); err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	return
+}
+
+func (d *ociDestination) pushManifest(ctx context.Context, tag string, manifest []byte) (digest string, err error) {
+	header := http.Header{}
+	header.Set("Content-Type", ociManifestMediaType)
+
+	resp, err := d.do(ctx, http.MethodPut, d.url("manifests/%s", tag), manifest, header)
+	if err != nil {
+		return
+	}
+	if err = expectOCIStatus(resp, "push manifest", http.StatusCreated); err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	digest = resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = "sha256:" + sha256Hex(manifest)
+	}
+
+	return
+}
+
+// manifest reads the manifest of reference, or nil if it does not exist.
+func (d *ociDestination) manifest(ctx context.Context, reference string) (manifest *ociManifest, digest string, err error) {
+	header := http.Header{}
+	header.Set("Accept", ociManifestMediaType)
+
+	resp, err := d.do(ctx, http.MethodGet, d.url("manifests/%s", reference), nil, header)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return
+	}
+	if err = expectOCIStatus(resp, "get manifest", http.StatusOK); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	manifest = &ociManifest{}
+	if err = json.Unmarshal(body, manifest); err != nil {
+		err = fmt.Errorf("failed to decode manifest: %w", err)
+		return
+	}
+
+	digest = resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = "sha256:" + sha256Hex(body)
+	}
+
+	return
+}
+
+// stateLayer returns the state layer of manifest, or an error if manifest is
+// not a tfsync state artifact.
+func stateLayer(manifest *ociManifest) (ociDescriptor, error) {
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != ociStateArtifactType {
+		return ociDescriptor{}, fmt.Errorf("manifest is not a %s artifact", ociStateArtifactType)
+	}
+	return manifest.Layers[0], nil
+}
+
+func (d *ociDestination) put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) (diag diag.Diagnostics) {
+	config, err := d.pushBlob(ctx, ociEmptyConfig)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to push config: %s", err))
+		return
+	}
+	config.MediaType = ociEmptyMediaType
+	config.Data = ociEmptyConfig
+
+	layer, err := d.pushBlob(ctx, contents)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to push state: %s", err))
+		return
+	}
+	layer.MediaType = ociStateArtifactType
+	layer.Annotations = map[string]string{ociAnnotationTitle: "terraform.tfstate"}
+
+	annotations := map[string]string{
+		ociAnnotationCreated: time.Now().UTC().Format(time.RFC3339),
+		ociAnnotationSerial:  strconv.FormatInt(metadata.Serial, 10),
+	}
+	for k, v := range map[string]string{
+		ociAnnotationWorkspaceId:   metadata.WorkspaceId,
+		ociAnnotationWorkspaceName: metadata.WorkspaceName,
+		ociAnnotationLineage:       metadata.Lineage,
+		ociAnnotationRunId:         metadata.RunId,
+	} {
+		if v != "" {
+			annotations[k] = v
+		}
+	}
+
+	manifest, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		ArtifactType:  ociStateArtifactType,
+		Config:        config,
+		Layers:        []ociDescriptor{layer},
+		Annotations:   annotations,
+	})
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to encode manifest: %s", err))
+		return
+	}
+
+	if _, err := d.pushManifest(ctx, key, manifest); err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to push %s: %s", key, err))
+		return
+	}
+
+	return
+}
+
+// tag points tag at the manifest of reference.
+func (d *ociDestination) tag(ctx context.Context, reference string, tag string) (digest string, diag diag.Diagnostics) {
+	header := http.Header{}
+	header.Set("Accept", ociManifestMediaType)
+
+	resp, err := d.do(ctx, http.MethodGet, d.url("manifests/%s", reference), nil, header)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", reference, err))
+		return
+	}
+	if err := expectOCIStatus(resp, "get manifest", http.StatusOK); err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", reference, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	manifest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to read manifest: %s", err))
+		return
+	}
+
+	digest, err = d.pushManifest(ctx, tag, manifest)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to tag %s as %s: %s", reference, tag, err))
+		return
+	}
+
+	return
+}
+
+func (d *ociDestination) get(ctx context.Context, key string) (contents []byte, diag diag.Diagnostics) {
+	manifest, _, err := d.manifest(ctx, key)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", key, err))
+		return
+	}
+	if manifest == nil {
+		return
+	}
+
+	layer, err := stateLayer(manifest)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", key, err))
+		return
+	}
+
+	resp, err := d.do(ctx, http.MethodGet, d.url("blobs/%s", layer.Digest), nil, nil)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", layer.Digest, err))
+		return
+	}
+	if err := expectOCIStatus(resp, "get blob", http.StatusOK); err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", layer.Digest, err))
+		return
+	}
+	defer resp.Body.Close()
+
+	contents, err = io.ReadAll(resp.Body)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to read blob: %s", err))
+		return
+	}
+
+	if got := "sha256:" + sha256Hex(contents); got != layer.Digest {
+		diag.AddError("oci client", fmt.Sprintf("digest mismatch for %s: expected %s, got %s", key, layer.Digest, got))
+		return nil, diag
+	}
+
+	return
+}
+
+// head reads the manifest of key only. Sha256 is the digest of the state
+// layer, so drift is detected without downloading the state.
+func (d *ociDestination) head(ctx context.Context, key string) (obj *destinationObject, diag diag.Diagnostics) {
+	manifest, _, err := d.manifest(ctx, key)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", key, err))
+		return
+	}
+	if manifest == nil {
+		return
+	}
+
+	layer, err := stateLayer(manifest)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", key, err))
+		return
+	}
+
+	obj = &destinationObject{
+		Size:        layer.Size,
+		Sha256:      strings.TrimPrefix(layer.Digest, "sha256:"),
+		WorkspaceId: manifest.Annotations[ociAnnotationWorkspaceId],
+	}
+
+	if t, err := time.Parse(time.RFC3339, manifest.Annotations[ociAnnotationCreated]); err == nil {
+		obj.LastModified = t
+	}
+
+	return
+}
+
+func (d *ociDestination) delete(ctx context.Context, key string) (diag diag.Diagnostics) {
+	manifest, digest, err := d.manifest(ctx, key)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to get %s: %s", key, err))
+		return
+	}
+	if manifest == nil {
+		return
+	}
+
+	resp, err := d.do(ctx, http.MethodDelete, d.url("manifests/%s", digest), nil, nil)
+	if err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to delete %s: %s", key, err))
+		return
+	}
+	if err := expectOCIStatus(resp, "delete manifest", http.StatusAccepted, http.StatusOK, http.StatusNotFound); err != nil {
+		diag.AddError("oci client", fmt.Sprintf("failed to delete %s: %s", key, err))
+		return
+	}
+	resp.Body.Close()
+
+	return
+}
+
+func (d *ociDestination) list(ctx context.Context, prefix string) (keys []string, diag diag.Diagnostics) {
+	next := d.url("tags/list")
+
+	for next != "" {
+		resp, err := d.do(ctx, http.MethodGet, next, nil, nil)
+		if err != nil {
+			diag.AddError("oci client", fmt.Sprintf("failed to list tags: %s", err))
+			return
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return
+		}
+		if err := expectOCIStatus(resp, "list tags", http.StatusOK); err != nil {
+			diag.AddError("oci client", fmt.Sprintf("failed to list tags: %s", err))
+			return
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			diag.AddError("oci client", fmt.Sprintf("failed to decode tags: %s", err))
+			return
+		}
+
+		for _, tag := range page.Tags {
+			if strings.HasPrefix(tag, prefix) {
+				keys = append(keys, tag)
+			}
+		}
+
+		next = ""
+		if link := ociNextLink(resp.Header.Get("Link")); link != "" {
+			u, err := resp.Request.URL.Parse(link)
+			if err != nil {
+				diag.AddError("oci client", fmt.Sprintf("failed to parse link: %s", err))
+				return
+			}
+			next = u.String()
+		}
+	}
+
+	return
+}
+
+// ociNextLink returns the target of a `Link: <url>; rel="next"` header.
+func ociNextLink(header string) string {
+	link, params, ok := strings.Cut(header, ";")
+	if !ok || !strings.Contains(params, `rel="next"`) {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(link), "<>")
+}
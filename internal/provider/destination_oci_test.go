@@ -0,0 +1,253 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeOCIRegistry is an OCI-distribution registry serving a single
+// repository. It challenges requests without a bearer token, which it hands
+// out for basic authentication as user and password.
+type fakeOCIRegistry struct {
+	mu        sync.Mutex
+	url       string
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	tags      map[string]string
+	uploads   int
+}
+
+func (f *fakeOCIRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/token" {
+		if user, password, _ := r.BasicAuth(); user != "user" || password != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token":"token"}`))
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, f.url))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, "/v2/backups/network/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	kind, ref, _ := strings.Cut(path, "/")
+	switch {
+	case kind == "blobs" && ref == "uploads/" && r.Method == http.MethodPost:
+		f.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/backups/network/blobs/uploads/%d", f.uploads))
+		w.WriteHeader(http.StatusAccepted)
+
+	case kind == "blobs" && strings.HasPrefix(ref, "uploads/") && r.Method == http.MethodPut:
+		digest := r.URL.Query().Get("digest")
+		if digest != "sha256:"+sha256Hex(body) {
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+			return
+		}
+		f.blobs[digest] = body
+		w.WriteHeader(http.StatusCreated)
+
+	case kind == "blobs" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		blob, ok := f.blobs[ref]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blob)
+
+	case kind == "manifests" && r.Method == http.MethodPut:
+		digest := "sha256:" + sha256Hex(body)
+		f.manifests[digest] = body
+		f.tags[ref] = digest
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+
+	case kind == "manifests" && r.Method == http.MethodGet:
+		digest := ref
+		if tagged, ok := f.tags[ref]; ok {
+			digest = tagged
+		}
+		manifest, ok := f.manifests[digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+		_, _ = w.Write(manifest)
+
+	case kind == "manifests" && r.Method == http.MethodDelete:
+		if _, ok := f.manifests[ref]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.manifests, ref)
+		maps.DeleteFunc(f.tags, func(tag string, digest string) bool { return digest == ref })
+		w.WriteHeader(http.StatusAccepted)
+
+	case kind == "tags" && ref == "list" && r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(map[string][]string{"tags": slices.Sorted(maps.Keys(f.tags))})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newFakeOCIDestination(t *testing.T) (*ociDestination, *fakeOCIRegistry) {
+	t.Helper()
+
+	f := &fakeOCIRegistry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string][]byte),
+		tags:      make(map[string]string),
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	f.url = srv.URL
+
+	dst, diags := newOCIDestination(ociOptions{
+		Repository: srv.Listener.Addr().String() + "/backups/network",
+		Username:   "user",
+		Password:   "password",
+		Insecure:   true,
+	}, 0)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	return dst, f
+}
+
+func TestOCIDestination(t *testing.T) {
+	dst, f := newFakeOCIDestination(t)
+	ctx := context.Background()
+
+	state := []byte(`{"serial":3}`)
+	metadata := destinationMetadata{WorkspaceId: "ws-abc", WorkspaceName: "network", Serial: 3, Lineage: "lineage"}
+
+	if _, diags := putState(ctx, dst, "3", stateEncoding{}, state, metadata); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if _, diags := dst.tag(ctx, "3", ociLatestTag); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	obj, diags := dst.head(ctx, ociLatestTag)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if obj == nil || obj.Sha256 != sha256Hex(state) || obj.WorkspaceId != "ws-abc" {
+		t.Fatalf("got %+v, want the state of ws-abc", obj)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(f.manifests[f.tags["3"]], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{
+		ociAnnotationWorkspaceName: "network",
+		ociAnnotationSerial:        "3",
+		ociAnnotationLineage:       "lineage",
+	} {
+		if got := manifest.Annotations[k]; got != want {
+			t.Errorf("got annotation %s %q, want %q", k, got, want)
+		}
+	}
+
+	contents, diags := dst.get(ctx, "3")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if string(contents) != string(state) {
+		t.Errorf("got %q, want %q", contents, state)
+	}
+
+	tags, diags := dst.list(ctx, "")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if want := []string{"3", ociLatestTag}; !slices.Equal(tags, want) {
+		t.Errorf("got tags %q, want %q", tags, want)
+	}
+
+	// Deleting latest deletes its manifest, which is also tagged 3.
+	if diags := dst.delete(ctx, ociLatestTag); diags.HasError() {
+		t.Fatal(diags)
+	}
+	for _, tag := range []string{"3", ociLatestTag} {
+		obj, diags := dst.head(ctx, tag)
+		if diags.HasError() {
+			t.Fatal(diags)
+		}
+		if obj != nil {
+			t.Errorf("got %+v for %s after delete, want nothing", obj, tag)
+		}
+	}
+}
+
+func TestOCIDestinationCorruptBlob(t *testing.T) {
+	dst, f := newFakeOCIDestination(t)
+	ctx := context.Background()
+
+	state := []byte(`{"serial":3}`)
+	if diags := dst.put(ctx, "3", state, destinationMetadata{}); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	f.blobs["sha256:"+sha256Hex(state)] = []byte(`{"serial":4}`)
+
+	if contents, diags := dst.get(ctx, "3"); !diags.HasError() {
+		t.Errorf("got %q and no error for a corrupt blob", contents)
+	}
+}
+
+func TestParseOCIChallenge(t *testing.T) {
+	for challenge, want := range map[string]map[string]string{
+		`Bearer realm="https://auth.example.com/token",service="registry"`: {
+			"realm":   "https://auth.example.com/token",
+			"service": "registry",
+		},
+		`Bearer realm="https://auth.example.com/token",service="registry",scope="repository:a/b:pull,push"`: {
+			"realm":   "https://auth.example.com/token",
+			"service": "registry",
+			"scope":   "repository:a/b:pull,push",
+		},
+		`Bearer realm=https://auth.example.com/token, Service=registry`: {
+			"realm":   "https://auth.example.com/token",
+			"service": "registry",
+		},
+	} {
+		scheme, got := parseOCIChallenge(challenge)
+		if scheme != "Bearer" || !maps.Equal(got, want) {
+			t.Errorf("%s: got %s %q, want Bearer %q", challenge, scheme, got, want)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// destinationResource implements the configure and crud methods of a
+// resource that syncs a workspace's state to one key of a destination.
+// Resources embed it and add their metadata and schema; everything specific
+// to a destination is supplied by the resource's model through
+// destinationModel.
+type destinationResource[M any, P destinationModel[M, D], D destination] struct {
+	softDelete bool
+	maxRetries int
+	tfeClient  *tfe.Client
+	stateCache *stateCache
+}
+
+// destinationResourceModel holds the attributes every destination resource
+// has. Resource models embed it.
+type destinationResourceModel struct {
+	Id          types.String   `tfsdk:"id"`
+	WorkspaceId types.String   `tfsdk:"workspace_id"`
+	IgnoreEmpty types.Bool     `tfsdk:"ignore_empty"`
+	Ignored     types.Bool     `tfsdk:"ignored"`
+	SoftDelete  types.Bool     `tfsdk:"soft_delete"`
+	Timeouts    timeouts.Value `tfsdk:"timeouts"`
+}
+
+func (m *destinationResourceModel) common() *destinationResourceModel {
+	return m
+}
+
+// destinationModel is implemented by the model of a destinationResource.
+type destinationModel[M any, D destination] interface {
+	*M
+
+	// common returns the embedded destinationResourceModel.
+	common() *destinationResourceModel
+
+	// destination returns the destination the state is synced to.
+	destination(ctx context.Context, maxRetries int) (D, diag.Diagnostics)
+
+	// id returns the id of the resource.
+	id() string
+
+	// key returns the key the state is stored at.
+	key() string
+
+	// location describes key for the soft delete warning.
+	location() string
+
+	// clear nulls the computed attributes when the workspace has no state.
+	clear()
+
+	// setContents sets the computed attributes from the workspace's state
+	// and the contents stored at key.
+	setContents(dst D, state []byte, contents []byte)
+}
+
+// destinationWorkspaceModel is implemented by models that depend on the
+// workspace, e.g. to expand a key template. A sync reads the workspace and
+// describes the synced state version in the destination's metadata.
+type destinationWorkspaceModel interface {
+	resolve(ws *tfe.Workspace)
+}
+
+// destinationTagsModel is implemented by models storing tags alongside the
+// state.
+type destinationTagsModel interface {
+	tags(ctx context.Context) (map[string]string, diag.Diagnostics)
+}
+
+// destinationEncodingModel is implemented by models storing the state in
+// another form than tfe does.
+type destinationEncodingModel interface {
+	encoding() stateEncoding
+}
+
+// destinationWriterModel is implemented by models writing the state
+// differently than putState to key does.
+type destinationWriterModel[D destination] interface {
+	write(ctx context.Context, dst D, ver *tfe.StateVersion, state []byte, metadata destinationMetadata) diag.Diagnostics
+}
+
+// destinationReaderModel is implemented by models reading the state
+// differently than getState from key does. found is false if the
+// destination does not hold the state.
+type destinationReaderModel[D destination] interface {
+	read(ctx context.Context, dst D, state []byte) (found bool, diag diag.Diagnostics)
+}
+
+// destinationCloser is implemented by destinations holding resources, e.g.
+// connections, that are released once the destination is no longer used.
+type destinationCloser interface {
+	close()
+}
+
+func (r *destinationResource[M, P, D]) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.softDelete = data.softDelete
+	r.maxRetries = data.maxRetries
+	r.tfeClient = data.tfeClient
+	r.stateCache = data.stateCache
+}
+
+func (r *destinationResource[M, P, D]) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	resp.Diagnostics.Append(validateDestinationResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data M
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := P(&data).common().Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp.Diagnostics.Append(r.sync(ctx, P(&data))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *destinationResource[M, P, D]) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	resp.Diagnostics.Append(validateDestinationResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data M
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	model := P(&data)
+	common := model.common()
+
+	timeout, d := common.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	state, d, ignored := getStateFile(ctx, r.tfeClient, r.stateCache, common.WorkspaceId.ValueString(), common.IgnoreEmpty.ValueBool())
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	common.Id = types.StringValue(model.id())
+	common.Ignored = types.BoolValue(ignored)
+
+	if ignored {
+		model.clear()
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	dst, d := model.destination(ctx, r.maxRetries)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if c, ok := any(dst).(destinationCloser); ok {
+		defer c.close()
+	}
+
+	found, d := readDestination(ctx, model, dst, state)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !found {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *destinationResource[M, P, D]) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	resp.Diagnostics.Append(validateDestinationResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan M
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := P(&plan).common().Timeouts.Update(ctx, defaultUpdateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp.Diagnostics.Append(r.sync(ctx, P(&plan))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *destinationResource[M, P, D]) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	resp.Diagnostics.Append(validateDestinationResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data M
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	model := P(&data)
+	common := model.common()
+
+	timeout, d := common.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if r.softDelete || common.SoftDelete.ValueBool() {
+		resp.Diagnostics.AddWarning("using soft delete", model.location())
+		return
+	}
+
+	dst, d := model.destination(ctx, r.maxRetries)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if c, ok := any(dst).(destinationCloser); ok {
+		defer c.close()
+	}
+
+	resp.Diagnostics.Append(dst.delete(ctx, model.key())...)
+}
+
+// sync writes the workspace's current state to the destination and sets the
+// computed attributes of data.
+func (r *destinationResource[M, P, D]) sync(ctx context.Context, data P) (diag diag.Diagnostics) {
+	common := data.common()
+	metadata := destinationMetadata{WorkspaceId: common.WorkspaceId.ValueString()}
+
+	if m, ok := any(data).(destinationTagsModel); ok {
+		tags, d := m.tags(ctx)
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+		metadata.Tags = tags
+	}
+
+	m, history := any(data).(destinationWorkspaceModel)
+	if history {
+		ws, err := r.tfeClient.Workspaces.ReadByID(ctx, metadata.WorkspaceId)
+		if err != nil {
+			diag.AddError("tfe client", fmt.Sprintf("failed to read workspace: %s", err))
+			return
+		}
+
+		m.resolve(ws)
+		metadata.WorkspaceName = ws.Name
+	}
+
+	common.Id = types.StringValue(data.id())
+
+	ver, state, d, ignored := getCurrentStateVersion(ctx, r.tfeClient, r.stateCache, metadata.WorkspaceId, common.IgnoreEmpty.ValueBool())
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	common.Ignored = types.BoolValue(ignored)
+
+	if ignored {
+		data.clear()
+		return
+	}
+
+	if history {
+		metadata.Serial = ver.Serial
+		if s, err := parseStateFile(state); err == nil {
+			metadata.Lineage = s.Lineage
+		}
+		if ver.Run != nil {
+			metadata.RunId = ver.Run.ID
+		}
+	}
+
+	dst, d := data.destination(ctx, r.maxRetries)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+	if c, ok := any(dst).(destinationCloser); ok {
+		defer c.close()
+	}
+
+	if w, ok := any(data).(destinationWriterModel[D]); ok {
+		diag.Append(w.write(ctx, dst, ver, state, metadata)...)
+	} else {
+		_, d = putState(ctx, dst, data.key(), destinationEncoding(data), state, metadata)
+		diag.Append(d...)
+	}
+	if diag.HasError() {
+		return
+	}
+
+	data.setContents(dst, state, state)
+
+	return
+}
+
+// readDestination reads the state stored for data and sets the computed
+// attributes of data from it.
+func readDestination[M any, P destinationModel[M, D], D destination](ctx context.Context, data P, dst D, state []byte) (found bool, diag diag.Diagnostics) {
+	if r, ok := any(data).(destinationReaderModel[D]); ok {
+		return r.read(ctx, dst, state)
+	}
+
+	contents, diag := getState(ctx, dst, data.key(), destinationEncoding(data))
+	if diag.HasError() || contents == nil {
+		return false, diag
+	}
+
+	data.setContents(dst, state, contents)
+
+	return true, diag
+}
+
+// destinationEncoding returns the encoding data stores the state with.
+func destinationEncoding(data any) stateEncoding {
+	if m, ok := data.(destinationEncodingModel); ok {
+		return m.encoding()
+	}
+
+	return stateEncoding{}
+}
+
+func validateDestinationResource[M any, P destinationModel[M, D], D destination](r *destinationResource[M, P, D]) (diag diag.Diagnostics) {
+	if r == nil {
+		diag.AddError("provider", "nil receiver")
+		return
+	}
+
+	if r.tfeClient == nil {
+		diag.AddError("provider", "nil tfe client")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,164 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const fakeTfeState = `{"version":4,"serial":3,"lineage":"lineage"}`
+
+// newFakeTfeClient returns a client of a tfe serving fakeTfeState as the
+// current state of ws-abc. ws-empty has no state. ws-hidden cannot be read
+// but is listed in the organization acme, ws-deleted does not exist.
+func newFakeTfeClient(t *testing.T) *tfe.Client {
+	t.Helper()
+
+	client, _ := newCountingFakeTfeClient(t)
+	return client
+}
+
+// newCountingFakeTfeClient is newFakeTfeClient, also returning a function
+// counting the requests made for a path.
+func newCountingFakeTfeClient(t *testing.T) (*tfe.Client, func(path string) int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	requests := make(map[string]int)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.Header().Set("TFP-API-Version", "2.5")
+
+		switch r.URL.Path {
+		case "/api/v2/ping":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v2/workspaces/ws-abc/current-state-version":
+			fmt.Fprintf(w, `{"data":{"id":"sv-abc","type":"state-versions","attributes":{"serial":3,"hosted-state-download-url":"%s/state"}}}`, srv.URL)
+		case "/api/v2/workspaces/ws-empty":
+			_, _ = w.Write([]byte(`{"data":{"id":"ws-empty","type":"workspaces","attributes":{"name":"empty"}}}`))
+		case "/api/v2/organizations":
+			_, _ = w.Write([]byte(`{"data":[{"id":"acme","type":"organizations","attributes":{"name":"acme"}}],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		case "/api/v2/organizations/acme/workspaces":
+			_, _ = w.Write([]byte(`{"data":[{"id":"ws-abc","type":"workspaces","attributes":{"name":"abc"}},{"id":"ws-hidden","type":"workspaces","attributes":{"name":"hidden"}}],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		case "/state":
+			_, _ = w.Write([]byte(fakeTfeState))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"status":"404","title":"not found"}]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := tfe.DefaultConfig()
+	cfg.Address = srv.URL
+	cfg.Token = "token"
+	configureTfeTransport(cfg, 0, nil)
+
+	client, err := tfe.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[path]
+	}
+}
+
+func newTestLocalFileModel(workspaceId string, path string) *LocalFileResourceModel {
+	return &LocalFileResourceModel{
+		destinationResourceModel: destinationResourceModel{
+			WorkspaceId: types.StringValue(workspaceId),
+			IgnoreEmpty: types.BoolValue(true),
+		},
+		Path:           types.StringValue(path),
+		FilePermission: types.StringNull(),
+		Compression:    types.StringValue(stateCompressionGzip),
+	}
+}
+
+func TestDestinationResourceSync(t *testing.T) {
+	r := &LocalFileResource{}
+	r.tfeClient = newFakeTfeClient(t)
+	r.stateCache = newStateCache(1<<20, "")
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.json.gz")
+	data := newTestLocalFileModel("ws-abc", path)
+
+	if diags := r.sync(ctx, data); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	want := sha256Contents([]byte(fakeTfeState))
+	if data.Id.ValueString() != "ws-abc/"+path || data.Ignored.ValueBool() {
+		t.Errorf("got id %s and ignored %s", data.Id, data.Ignored)
+	}
+	if !data.StateContentsSha256.Equal(want) || !data.FileContentsSha256.Equal(want) {
+		t.Errorf("got checksums %s and %s, want %s", data.StateContentsSha256, data.FileContentsSha256, want)
+	}
+
+	// The file is stored compressed, and read back decompressed.
+	if contents, err := os.ReadFile(path); err != nil || string(contents) == fakeTfeState {
+		t.Errorf("got file contents %q (%v), want them compressed", contents, err)
+	}
+
+	dst, diags := data.destination(ctx, 0)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	data.clear()
+	found, diags := readDestination(ctx, data, dst, []byte(fakeTfeState))
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if !found || !data.FileContentsSha256.Equal(want) {
+		t.Errorf("got found %t and checksum %s, want %s", found, data.FileContentsSha256, want)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if found, diags := readDestination(ctx, data, dst, []byte(fakeTfeState)); diags.HasError() || found {
+		t.Errorf("got found %t (%v) for a removed file", found, diags)
+	}
+}
+
+func TestDestinationResourceSyncIgnoreEmpty(t *testing.T) {
+	r := &LocalFileResource{}
+	r.tfeClient = newFakeTfeClient(t)
+	r.stateCache = newStateCache(1<<20, "")
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	data := newTestLocalFileModel("ws-empty", path)
+
+	if diags := r.sync(context.Background(), data); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if !data.Ignored.ValueBool() || !data.StateContentsSha256.IsNull() || !data.FileContentsSha256.IsNull() {
+		t.Errorf("got ignored %s and checksums %s and %s, want ignored and null checksums", data.Ignored, data.StateContentsSha256, data.FileContentsSha256)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("file was written for a workspace without state")
+	}
+}
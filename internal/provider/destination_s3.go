@@ -0,0 +1,284 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var _ destination = &s3Destination{}
+
+// s3Destination stores state as objects in an s3 bucket.
+type s3Destination struct {
+	client   *s3.Client
+	bucket   string
+	kmsKeyId string
+	uploads  semaphore
+}
+
+func newS3Destination(client *s3.Client, bucket string, kmsKeyId string, uploads semaphore) *s3Destination {
+	return &s3Destination{client: client, bucket: bucket, kmsKeyId: kmsKeyId, uploads: uploads}
+}
+
+func (d *s3Destination) put(ctx context.Context, key string, contents []byte, metadata destinationMetadata) diag.Diagnostics {
+	return putS3ObjectContents(ctx, d.client, d.uploads, &putObjectOptions{
+		Bucket:      d.bucket,
+		Key:         key,
+		KmsKeyId:    d.kmsKeyId,
+		WorkspaceId: metadata.WorkspaceId,
+		Contents:    contents,
+		Tags:        metadata.Tags,
+	})
+}
+
+func (d *s3Destination) get(ctx context.Context, key string) (contents []byte, diag diag.Diagnostics) {
+	resp, err := d.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *s3types.NoSuchKey
+		if errors.As(err, &notFound) {
+			return
+		}
+
+		diag.AddError("s3 client", fmt.Sprintf("failed to get object: %s", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	contents, err = io.ReadAll(resp.Body)
+	if err != nil {
+		diag.AddError("s3 client", fmt.Sprintf("failed to read body: %s", err))
+		return
+	}
+
+	return
+}
+
+func (d *s3Destination) head(ctx context.Context, key string) (obj *destinationObject, diag diag.Diagnostics) {
+	resp, err := d.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(d.bucket),
+		Key:          aws.String(key),
+		ChecksumMode: s3types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		var notFound *s3types.NotFound
+		if errors.As(err, &notFound) {
+			return
+		}
+
+		diag.AddError("s3 client", fmt.Sprintf("failed to head object: %s", err))
+		return
+	}
+
+	obj = &destinationObject{
+		Size:         aws.ToInt64(resp.ContentLength),
+		LastModified: aws.ToTime(resp.LastModified),
+		WorkspaceId:  resp.Metadata[s3MetadataWorkspaceId],
+	}
+
+	if sum, err := base64.StdEncoding.DecodeString(aws.ToString(resp.ChecksumSHA256)); err == nil && len(sum) == sha256.Size {
+		obj.Sha256 = hex.EncodeToString(sum)
+	}
+
+	return
+}
+
+func (d *s3Destination) delete(ctx context.Context, key string) diag.Diagnostics {
+	return deleteS3Object(ctx, d.client, d.bucket, key)
+}
+
+func (d *s3Destination) list(ctx context.Context, prefix string) ([]string, diag.Diagnostics) {
+	return listS3Keys(ctx, d.client, d.bucket, prefix)
+}
+
+// getVerifiedS3ObjectContents reads an object written by putS3ObjectContents
+// and checks its contents against the sha256 checksum s3 recorded on upload.
+// A placeholder marker is an error, as it holds no state. An empty versionId
+// reads the latest version.
+func getVerifiedS3ObjectContents(ctx context.Context, client *s3.Client, bucket string, key string, versionId string) (contents []byte, diag diag.Diagnostics) {
+	input := &s3.GetObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: s3types.ChecksumModeEnabled,
+	}
+	if versionId != "" {
+		input.VersionId = aws.String(versionId)
+	}
+
+	resp, err := client.GetObject(ctx, input)
+	if err != nil {
+		diag.AddError("s3 client", fmt.Sprintf("failed to get object: %s", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	contents, err = io.ReadAll(resp.Body)
+	if err != nil {
+		diag.AddError("s3 client", fmt.Sprintf("failed to read body: %s", err))
+		return
+	}
+
+	if resp.ChecksumSHA256 == nil {
+		diag.AddWarning("s3 client", fmt.Sprintf("s3://%s/%s has no sha256 checksum, its contents could not be verified", bucket, key))
+		return
+	}
+
+	hash := sha256.Sum256(contents)
+	if got := base64.StdEncoding.EncodeToString(hash[:]); aws.ToString(resp.ChecksumSHA256) != got {
+		diag.AddError("s3 client", fmt.Sprintf("checksum mismatch for s3://%s/%s: expected sha256 %s, got %s", bucket, key, aws.ToString(resp.ChecksumSHA256), got))
+		return
+	}
+
+	if marker, ok := parsePlaceholderMarker(contents); ok {
+		diag.AddError("no state", fmt.Sprintf("s3://%s/%s is a placeholder for workspace %s, which had no state as of %s", bucket, key, marker.WorkspaceId, marker.CreatedAt))
+		return
+	}
+
+	return
+}
+
+// s3MetadataWorkspaceId is the user metadata key recording which workspace an
+// object was synced from.
+const s3MetadataWorkspaceId = "tfsync-workspace-id"
+
+type putObjectOptions struct {
+	Bucket      string
+	Key         string
+	KmsKeyId    string
+	WorkspaceId string
+	Contents    []byte
+	Tags        map[string]string
+}
+
+func (o *putObjectOptions) validate() (diag diag.Diagnostics) {
+	if o == nil {
+		diag.AddError("putObjectOptions", "nil receiver")
+		return
+	}
+	if o.Bucket == "" {
+		diag.AddError("putObjectOptions", "empty bucket")
+	}
+	if o.Key == "" {
+		diag.AddError("putObjectOptions", "empty key")
+	}
+	if len(o.Contents) == 0 {
+		diag.AddError("putObjectOptions", "empty contents")
+	}
+
+	return
+}
+
+func putS3ObjectContents(ctx context.Context, client *s3.Client, uploads semaphore, o *putObjectOptions) (diag diag.Diagnostics) {
+	diag.Append(o.validate()...)
+	if diag.HasError() {
+		return
+	}
+
+	ctx = tflog.SetField(ctx, "bucket", o.Bucket)
+	ctx = tflog.SetField(ctx, "key", o.Key)
+
+	tflog.Debug(ctx, "tfsync putobject")
+
+	input := &s3.PutObjectInput{
+		Bucket:            aws.String(o.Bucket),
+		Key:               aws.String(o.Key),
+		Body:              io.NopCloser(bytes.NewReader(o.Contents)),
+		ContentLength:     aws.Int64(int64(len(o.Contents))),
+		ContentType:       aws.String("application/json"),
+		ChecksumAlgorithm: s3types.ChecksumAlgorithmSha256,
+	}
+
+	if o.KmsKeyId != "" {
+		input.ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(o.KmsKeyId)
+	}
+
+	if o.WorkspaceId != "" {
+		input.Metadata = map[string]string{s3MetadataWorkspaceId: o.WorkspaceId}
+	}
+
+	if len(o.Tags) > 0 {
+		input.Tagging = aws.String(newTags(o.Tags))
+	}
+
+	if err := uploads.acquire(ctx); err != nil {
+		diag.AddError("s3 client", fmt.Sprintf("failed waiting to put object: %s", err))
+		return
+	}
+	defer uploads.release()
+
+	_, err := client.PutObject(ctx, input)
+	if err != nil {
+		diag.AddError("s3 client", fmt.Sprintf("failed s3 put object: %s", err))
+		return
+	}
+
+	return
+}
+
+// listS3Keys returns the key of every object in bucket beginning with prefix.
+func listS3Keys(ctx context.Context, client *s3.Client, bucket string, prefix string) (keys []string, diag diag.Diagnostics) {
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(bucket)}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	p := s3.NewListObjectsV2Paginator(client, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			diag.AddError("s3 client", fmt.Sprintf("failed to list objects: %s", err))
+			return
+		}
+
+		for _, o := range page.Contents {
+			keys = append(keys, aws.ToString(o.Key))
+		}
+	}
+
+	return
+}
+
+// putS3ObjectTags replaces the tags of an existing object.
+func putS3ObjectTags(ctx context.Context, client *s3.Client, bucket string, key string, tags map[string]string) (diag diag.Diagnostics) {
+	_, err := client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &s3types.Tagging{TagSet: sortedTags(tags)},
+	})
+	if err != nil {
+		diag.AddError("s3 client", fmt.Sprintf("failed to put object tags: %s", err))
+		return
+	}
+
+	return
+}
+
+func deleteS3Object(ctx context.Context, client *s3.Client, bucket string, key string) (diag diag.Diagnostics) {
+	_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		diag.AddError("s3 client", fmt.Sprintf("failed to delete s3 object: %s", err))
+		return
+	}
+
+	return
+}
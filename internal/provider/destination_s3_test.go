@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetVerifiedS3ObjectContents(t *testing.T) {
+	marker, err := newPlaceholderMarker("ws-abc", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeS3Objects{
+		objects: map[string][]byte{
+			"/backups/state":       []byte(fakeTfeState),
+			"/backups/placeholder": marker,
+			"/backups/corrupted":   []byte(fakeTfeState),
+		},
+		checksums: map[string]string{"/backups/corrupted": "recorded"},
+	}
+	_, client := newFakeS3Client(t, f)
+	sum := sha256.Sum256([]byte(fakeTfeState))
+	ctx := context.Background()
+
+	contents, diags := getVerifiedS3ObjectContents(ctx, client, "backups", "state", "")
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if string(contents) != fakeTfeState {
+		t.Errorf("got %q, want the state", contents)
+	}
+
+	// The sdk verifies the checksum before it is compared again.
+	for key, want := range map[string][]string{
+		"placeholder": {"is a placeholder for workspace ws-abc, which had no state as of 2024-05-01T00:00:00Z"},
+		"corrupted":   {"recorded", base64.StdEncoding.EncodeToString(sum[:])},
+	} {
+		_, diags := getVerifiedS3ObjectContents(ctx, client, "backups", key, "")
+		if !diags.HasError() {
+			t.Errorf("%s: got no error", key)
+			continue
+		}
+		for _, w := range want {
+			if !strings.Contains(diags[0].Detail(), w) {
+				t.Errorf("%s: got %q, want it to contain %q", key, diags[0].Detail(), w)
+			}
+		}
+	}
+}
+
+func TestParsePlaceholderMarker(t *testing.T) {
+	marker, err := newPlaceholderMarker("ws-abc", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for contents, want := range map[string]bool{
+		string(marker):        true,
+		fakeTfeState:          false,
+		`{"serial":1}`:        false,
+		"\x1f\x8b compressed": false,
+	} {
+		if _, ok := parsePlaceholderMarker([]byte(contents)); ok != want {
+			t.Errorf("%q: got placeholder %t, want %t", contents, ok, want)
+		}
+	}
+}
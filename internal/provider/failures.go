@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	failurePolicyFailFast         = "fail_fast"
+	failurePolicyContinueAndError = "continue_and_error"
+	failurePolicyContinueAndWarn  = "continue_and_warn"
+
+	syncStageDownload = "download"
+	syncStageUpload   = "upload"
+	syncStageVerify   = "verify"
+)
+
+// syncFailureModel records why one workspace of a multi-workspace sync
+// failed, so that the remaining workspaces can still be synced.
+type syncFailureModel struct {
+	Workspace types.String `tfsdk:"workspace"`
+	Stage     types.String `tfsdk:"stage"`
+	Error     types.String `tfsdk:"error"`
+}
+
+var syncFailureAttrTypes = map[string]attr.Type{
+	"workspace": types.StringType,
+	"stage":     types.StringType,
+	"error":     types.StringType,
+}
+
+func failurePolicyAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: fmt.Sprintf("what to do when a workspace fails to sync. `%s` stops syncing further workspaces and errors, `%s` syncs the remaining workspaces and then errors, `%s` syncs the remaining workspaces and only warns. Defaults to `%s`", failurePolicyFailFast, failurePolicyContinueAndError, failurePolicyContinueAndWarn, failurePolicyContinueAndError),
+		Optional:            true,
+		Validators: []validator.String{
+			stringvalidator.OneOf(failurePolicyFailFast, failurePolicyContinueAndError, failurePolicyContinueAndWarn),
+		},
+	}
+}
+
+func failuresAttribute() schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		MarkdownDescription: "workspaces that failed to sync during the last apply",
+		Computed:            true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"workspace": schema.StringAttribute{
+					MarkdownDescription: "terraform workspace name",
+					Computed:            true,
+				},
+				"stage": schema.StringAttribute{
+					MarkdownDescription: fmt.Sprintf("stage that failed, one of `%s`, `%s` or `%s`", syncStageDownload, syncStageUpload, syncStageVerify),
+					Computed:            true,
+				},
+				"error": schema.StringAttribute{
+					MarkdownDescription: "error message",
+					Computed:            true,
+				},
+			},
+		},
+	}
+}
+
+func failurePolicy(v types.String) string {
+	if v.IsNull() || v.IsUnknown() {
+		return failurePolicyContinueAndError
+	}
+	return v.ValueString()
+}
+
+func newSyncFailure(workspace string, stage string, d diag.Diagnostics) *syncFailureModel {
+	var details []string
+	for _, e := range d.Errors() {
+		details = append(details, e.Detail())
+	}
+
+	return &syncFailureModel{
+		Workspace: types.StringValue(workspace),
+		Stage:     types.StringValue(stage),
+		Error:     types.StringValue(strings.Join(details, "; ")),
+	}
+}
+
+// reportSyncFailures summarises failures as a single error or warning
+// depending on policy.
+func reportSyncFailures(policy string, failures []syncFailureModel, total int) (diag diag.Diagnostics) {
+	if len(failures) == 0 {
+		return
+	}
+
+	var lines []string
+	for _, f := range failures {
+		lines = append(lines, fmt.Sprintf("%s (%s): %s", f.Workspace.ValueString(), f.Stage.ValueString(), f.Error.ValueString()))
+	}
+
+	detail := fmt.Sprintf("failed to sync %d of %d workspaces:\n%s", len(failures), total, strings.Join(lines, "\n"))
+
+	if policy == failurePolicyContinueAndWarn {
+		diag.AddWarning("sync failures", detail)
+		return
+	}
+
+	diag.AddError("sync failures", detail)
+
+	return
+}
@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &GCSObjectResource{}
+
+func NewGCSObjectResource() resource.Resource {
+	return &GCSObjectResource{}
+}
+
+type GCSObjectResource struct {
+	destinationResource[GCSObjectResourceModel, *GCSObjectResourceModel, *gcsDestination]
+}
+
+type GCSObjectResourceModel struct {
+	destinationResourceModel
+
+	Bucket               types.String `tfsdk:"bucket"`
+	Name                 types.String `tfsdk:"name"`
+	Endpoint             types.String `tfsdk:"endpoint"`
+	Credentials          types.String `tfsdk:"credentials"`
+	AccessToken          types.String `tfsdk:"access_token"`
+	Anonymous            types.Bool   `tfsdk:"anonymous"`
+	KmsKeyName           types.String `tfsdk:"kms_key_name"`
+	StateContentsSha256  types.String `tfsdk:"state_contents_sha256"`
+	ObjectContentsSha256 types.String `tfsdk:"object_contents_sha256"`
+	Metadata             types.Map    `tfsdk:"metadata"`
+}
+
+func (r *GCSObjectResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_gcs_object"
+}
+
+func (r *GCSObjectResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to sync tf-state to a google cloud storage object",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "workspace id, bucket and object name",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"bucket": schema.StringAttribute{
+				MarkdownDescription: "gcs bucket",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(3, 222),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "object name",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 1024),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "json api url, e.g. `http://localhost:4443/storage/v1/` for fake-gcs-server. Defaults to the google cloud storage api",
+				Optional:            true,
+				Validators:          httpAddressValidators(),
+			},
+			"credentials": schema.StringAttribute{
+				MarkdownDescription: "service account key json. Defaults to application default credentials. Conflicts with `access_token` and `anonymous`",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("access_token"), path.MatchRoot("anonymous")),
+				},
+			},
+			"access_token": schema.StringAttribute{
+				MarkdownDescription: "oauth2 access token. Conflicts with `anonymous`",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("anonymous")),
+				},
+			},
+			"anonymous": schema.BoolAttribute{
+				MarkdownDescription: "send requests without credentials, e.g. to fake-gcs-server",
+				Optional:            true,
+			},
+			"kms_key_name": schema.StringAttribute{
+				MarkdownDescription: "cloud kms key the object is encrypted with, e.g. `projects/p/locations/l/keyRings/r/cryptoKeys/k`",
+				Optional:            true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state",
+				Computed:            true,
+			},
+			"object_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of gcs object contents",
+				Computed:            true,
+			},
+			"ignore_empty": schema.BoolAttribute{
+				MarkdownDescription: "ignore if no state is found",
+				Optional:            true,
+			},
+			"ignored": schema.BoolAttribute{
+				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
+				Computed:            true,
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: "use soft delete",
+				Optional:            true,
+			},
+			"metadata": schema.MapAttribute{
+				MarkdownDescription: "custom object metadata. The keys `tfsync-sha256` and `tfsync-workspace-id` are reserved",
+				Optional:            true,
+				ElementType:         types.StringType,
+				Validators:          gcsMetadataValidators(),
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (m *GCSObjectResourceModel) destination(ctx context.Context, maxRetries int) (*gcsDestination, diag.Diagnostics) {
+	return newGCSDestination(ctx, gcsOptions{
+		Bucket:      m.Bucket.ValueString(),
+		Endpoint:    m.Endpoint.ValueString(),
+		Credentials: m.Credentials.ValueString(),
+		AccessToken: m.AccessToken.ValueString(),
+		Anonymous:   m.Anonymous.ValueBool(),
+		KmsKeyName:  m.KmsKeyName.ValueString(),
+	}, maxRetries)
+}
+
+func (m *GCSObjectResourceModel) id() string {
+	return fmt.Sprintf("%s/%s/%s", m.WorkspaceId.ValueString(), m.Bucket.ValueString(), m.Name.ValueString())
+}
+
+func (m *GCSObjectResourceModel) key() string {
+	return m.Name.ValueString()
+}
+
+func (m *GCSObjectResourceModel) location() string {
+	return fmt.Sprintf("bucket: %s, name: %s", m.Bucket.ValueString(), m.Name.ValueString())
+}
+
+func (m *GCSObjectResourceModel) tags(ctx context.Context) (metadata map[string]string, diag diag.Diagnostics) {
+	diag.Append(m.Metadata.ElementsAs(ctx, &metadata, true)...)
+	return
+}
+
+func (m *GCSObjectResourceModel) clear() {
+	m.StateContentsSha256 = types.StringNull()
+	m.ObjectContentsSha256 = types.StringNull()
+}
+
+func (m *GCSObjectResourceModel) setContents(dst *gcsDestination, state []byte, contents []byte) {
+	m.StateContentsSha256 = sha256Contents(state)
+	m.ObjectContentsSha256 = sha256Contents(contents)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &GitRepositoryResource{}
+
+func NewGitRepositoryResource() resource.Resource {
+	return &GitRepositoryResource{}
+}
+
+type GitRepositoryResource struct {
+	destinationResource[GitRepositoryResourceModel, *GitRepositoryResourceModel, *gitDestination]
+}
+
+type GitRepositoryResourceModel struct {
+	destinationResourceModel
+
+	Url                      types.String `tfsdk:"url"`
+	Branch                   types.String `tfsdk:"branch"`
+	Path                     types.String `tfsdk:"path"`
+	ResolvedPath             types.String `tfsdk:"resolved_path"`
+	AuthorName               types.String `tfsdk:"author_name"`
+	AuthorEmail              types.String `tfsdk:"author_email"`
+	SigningKey               types.String `tfsdk:"signing_key"`
+	SigningFormat            types.String `tfsdk:"signing_format"`
+	SSHCommand               types.String `tfsdk:"ssh_command"`
+	PushRetries              types.Int64  `tfsdk:"push_retries"`
+	CommitSha                types.String `tfsdk:"commit_sha"`
+	StateContentsSha256      types.String `tfsdk:"state_contents_sha256"`
+	RepositoryContentsSha256 types.String `tfsdk:"repository_contents_sha256"`
+}
+
+func (r *GitRepositoryResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_git_repository"
+}
+
+func (r *GitRepositoryResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to commit tf-state to a git repository, giving a history, diffs and blame of state changes. Requires `git` on the `PATH`. Commits record the workspace, serial and run as trailers, e.g. `Serial: 42`",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "workspace id, url, branch and path",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"url": schema.StringAttribute{
+				MarkdownDescription: "url of the repository, e.g. `file:///srv/git/state.git` or `git@github.com:example/state.git`. The repository may be empty",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"branch": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("branch to commit to. It is created if it does not exist. Defaults to `%s`", defaultGitBranch),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"path": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("path of the state within the repository. Supports the placeholders %s, e.g. `{project_id}/{workspace_name}/terraform.tfstate`", keyTemplateMarkdown),
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+					gitPathValidator{},
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"resolved_path": schema.StringAttribute{
+				MarkdownDescription: "`path` with its placeholders substituted",
+				Computed:            true,
+			},
+			"author_name": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("author and committer name of commits. Defaults to `%s`", defaultGitAuthorName),
+				Optional:            true,
+			},
+			"author_email": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("author and committer email of commits. Defaults to `%s`", defaultGitAuthorEmail),
+				Optional:            true,
+			},
+			"signing_key": schema.StringAttribute{
+				MarkdownDescription: "key to sign commits with, as understood by git's `user.signingKey`, e.g. a gpg key id or the path of an ssh key",
+				Optional:            true,
+			},
+			"signing_format": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("format of `signing_key`, one of `%s` or `%s`. Defaults to `%s`", gitSigningFormatOpenPGP, gitSigningFormatSSH, gitSigningFormatOpenPGP),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(gitSigningFormatOpenPGP, gitSigningFormatSSH),
+					stringvalidator.AlsoRequires(path.MatchRoot("signing_key")),
+				},
+			},
+			"ssh_command": schema.StringAttribute{
+				MarkdownDescription: "ssh command used for ssh urls, e.g. `ssh -i /etc/tfsync/deploy_key -o IdentitiesOnly=yes`",
+				Optional:            true,
+			},
+			"push_retries": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("number of times a push rejected because the branch moved on is retried on top of the new branch tip. Defaults to `%d`", defaultGitPushRetries),
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"commit_sha": schema.StringAttribute{
+				MarkdownDescription: "last commit of the state",
+				Computed:            true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state",
+				Computed:            true,
+			},
+			"repository_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of the state committed at `resolved_path`",
+				Computed:            true,
+			},
+			"ignore_empty": schema.BoolAttribute{
+				MarkdownDescription: "ignore if no state is found",
+				Optional:            true,
+			},
+			"ignored": schema.BoolAttribute{
+				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
+				Computed:            true,
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: "keep the state in the repository when the resource is destroyed. Otherwise its removal is committed",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (m *GitRepositoryResourceModel) destination(ctx context.Context, maxRetries int) (*gitDestination, diag.Diagnostics) {
+	pushRetries := int64(defaultGitPushRetries)
+	if !m.PushRetries.IsNull() {
+		pushRetries = m.PushRetries.ValueInt64()
+	}
+
+	return newGitDestination(gitOptions{
+		URL:           m.Url.ValueString(),
+		Branch:        m.Branch.ValueString(),
+		AuthorName:    m.AuthorName.ValueString(),
+		AuthorEmail:   m.AuthorEmail.ValueString(),
+		SigningKey:    m.SigningKey.ValueString(),
+		SigningFormat: m.SigningFormat.ValueString(),
+		SSHCommand:    m.SSHCommand.ValueString(),
+		PushRetries:   int(pushRetries),
+	}), nil
+}
+
+// resolve expands the path template with the workspace.
+func (m *GitRepositoryResourceModel) resolve(ws *tfe.Workspace) {
+	var organization string
+	if ws.Organization != nil {
+		organization = ws.Organization.Name
+	}
+
+	m.ResolvedPath = types.StringValue(expandKeyTemplate(m.Path.ValueString(), organization, ws))
+}
+
+func (m *GitRepositoryResourceModel) id() string {
+	return fmt.Sprintf("%s/%s/%s/%s", m.WorkspaceId.ValueString(), m.Url.ValueString(), m.Branch.ValueString(), m.ResolvedPath.ValueString())
+}
+
+func (m *GitRepositoryResourceModel) key() string {
+	return m.ResolvedPath.ValueString()
+}
+
+func (m *GitRepositoryResourceModel) location() string {
+	return fmt.Sprintf("url: %s, path: %s", m.Url.ValueString(), m.ResolvedPath.ValueString())
+}
+
+func (m *GitRepositoryResourceModel) clear() {
+	m.CommitSha = types.StringNull()
+	m.StateContentsSha256 = types.StringNull()
+	m.RepositoryContentsSha256 = types.StringNull()
+}
+
+func (m *GitRepositoryResourceModel) setContents(dst *gitDestination, state []byte, contents []byte) {
+	m.CommitSha = types.StringValue(dst.commit)
+	m.StateContentsSha256 = sha256Contents(state)
+	m.RepositoryContentsSha256 = sha256Contents(contents)
+}
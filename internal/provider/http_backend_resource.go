@@ -0,0 +1,196 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &HTTPBackendResource{}
+
+func NewHTTPBackendResource() resource.Resource {
+	return &HTTPBackendResource{}
+}
+
+type HTTPBackendResource struct {
+	destinationResource[HTTPBackendResourceModel, *HTTPBackendResourceModel, *httpBackendDestination]
+}
+
+type HTTPBackendResourceModel struct {
+	destinationResourceModel
+
+	Address                types.String `tfsdk:"address"`
+	UpdateMethod           types.String `tfsdk:"update_method"`
+	LockAddress            types.String `tfsdk:"lock_address"`
+	LockMethod             types.String `tfsdk:"lock_method"`
+	UnlockAddress          types.String `tfsdk:"unlock_address"`
+	UnlockMethod           types.String `tfsdk:"unlock_method"`
+	Username               types.String `tfsdk:"username"`
+	Password               types.String `tfsdk:"password"`
+	SkipCertVerification   types.Bool   `tfsdk:"skip_cert_verification"`
+	ClientCertificatePem   types.String `tfsdk:"client_certificate_pem"`
+	ClientPrivateKeyPem    types.String `tfsdk:"client_private_key_pem"`
+	ClientCaCertificatePem types.String `tfsdk:"client_ca_certificate_pem"`
+	StateContentsSha256    types.String `tfsdk:"state_contents_sha256"`
+	BackendContentsSha256  types.String `tfsdk:"backend_contents_sha256"`
+}
+
+func (r *HTTPBackendResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_http_backend"
+}
+
+func (r *HTTPBackendResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to sync tf-state to a server speaking terraform's [http backend](https://developer.hashicorp.com/terraform/language/backend/http) protocol, e.g. GitLab-managed terraform state",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "workspace id and address",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"address": schema.StringAttribute{
+				MarkdownDescription: "address of the state",
+				Required:            true,
+				Validators:          httpAddressValidators(),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"update_method": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("http method used to write the state. Defaults to `%s`", defaultHTTPBackendUpdateMethod),
+				Optional:            true,
+				Validators:          httpMethodValidators(),
+			},
+			"lock_address": schema.StringAttribute{
+				MarkdownDescription: "address to lock the state at while it is written. Locking is disabled if not set",
+				Optional:            true,
+				Validators:          httpAddressValidators(),
+			},
+			"lock_method": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("http method used to lock the state. Defaults to `%s`", defaultHTTPBackendLockMethod),
+				Optional:            true,
+				Validators:          httpMethodValidators(),
+			},
+			"unlock_address": schema.StringAttribute{
+				MarkdownDescription: "address to unlock the state at. Defaults to `lock_address`",
+				Optional:            true,
+				Validators:          httpAddressValidators(),
+			},
+			"unlock_method": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("http method used to unlock the state. Defaults to `%s`", defaultHTTPBackendUnlockMethod),
+				Optional:            true,
+				Validators:          httpMethodValidators(),
+			},
+			"username": schema.StringAttribute{
+				MarkdownDescription: "username for basic authentication",
+				Optional:            true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "password for basic authentication, e.g. a GitLab access token",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"skip_cert_verification": schema.BoolAttribute{
+				MarkdownDescription: "skip verification of the server's tls certificate",
+				Optional:            true,
+			},
+			"client_certificate_pem": schema.StringAttribute{
+				MarkdownDescription: "pem encoded client certificate for mutual tls",
+				Optional:            true,
+			},
+			"client_private_key_pem": schema.StringAttribute{
+				MarkdownDescription: "pem encoded private key of `client_certificate_pem`",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"client_ca_certificate_pem": schema.StringAttribute{
+				MarkdownDescription: "pem encoded ca certificates to verify the server with, instead of the system roots",
+				Optional:            true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state",
+				Computed:            true,
+			},
+			"backend_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of the state stored at `address`",
+				Computed:            true,
+			},
+			"ignore_empty": schema.BoolAttribute{
+				MarkdownDescription: "ignore if no state is found",
+				Optional:            true,
+			},
+			"ignored": schema.BoolAttribute{
+				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
+				Computed:            true,
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: "keep the state at `address` when the resource is destroyed",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (m *HTTPBackendResourceModel) destination(ctx context.Context, maxRetries int) (*httpBackendDestination, diag.Diagnostics) {
+	return newHTTPBackendDestination(httpBackendOptions{
+		UpdateMethod:           m.UpdateMethod.ValueString(),
+		LockAddress:            m.LockAddress.ValueString(),
+		LockMethod:             m.LockMethod.ValueString(),
+		UnlockAddress:          m.UnlockAddress.ValueString(),
+		UnlockMethod:           m.UnlockMethod.ValueString(),
+		Username:               m.Username.ValueString(),
+		Password:               m.Password.ValueString(),
+		SkipCertVerification:   m.SkipCertVerification.ValueBool(),
+		ClientCertificatePem:   m.ClientCertificatePem.ValueString(),
+		ClientPrivateKeyPem:    m.ClientPrivateKeyPem.ValueString(),
+		ClientCaCertificatePem: m.ClientCaCertificatePem.ValueString(),
+	}, maxRetries)
+}
+
+func (m *HTTPBackendResourceModel) id() string {
+	return fmt.Sprintf("%s/%s", m.WorkspaceId.ValueString(), m.Address.ValueString())
+}
+
+func (m *HTTPBackendResourceModel) key() string {
+	return m.Address.ValueString()
+}
+
+func (m *HTTPBackendResourceModel) location() string {
+	return fmt.Sprintf("address: %s", m.Address.ValueString())
+}
+
+func (m *HTTPBackendResourceModel) clear() {
+	m.StateContentsSha256 = types.StringNull()
+	m.BackendContentsSha256 = types.StringNull()
+}
+
+func (m *HTTPBackendResourceModel) setContents(dst *httpBackendDestination, state []byte, contents []byte) {
+	m.StateContentsSha256 = sha256Contents(state)
+	m.BackendContentsSha256 = sha256Contents(contents)
+}
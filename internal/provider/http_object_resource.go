@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &HTTPObjectResource{}
+
+func NewHTTPObjectResource() resource.Resource {
+	return &HTTPObjectResource{}
+}
+
+type HTTPObjectResource struct {
+	destinationResource[HTTPObjectResourceModel, *HTTPObjectResourceModel, *httpDestination]
+}
+
+type HTTPObjectResourceModel struct {
+	destinationResourceModel
+
+	URL                  types.String `tfsdk:"url"`
+	Key                  types.String `tfsdk:"key"`
+	Headers              types.Map    `tfsdk:"headers"`
+	Compression          types.String `tfsdk:"compression"`
+	EncryptionKey        types.String `tfsdk:"encryption_key"`
+	StateContentsSha256  types.String `tfsdk:"state_contents_sha256"`
+	ObjectContentsSha256 types.String `tfsdk:"object_contents_sha256"`
+}
+
+func (r *HTTPObjectResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_http_object"
+}
+
+func (r *HTTPObjectResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to sync tf-state to a server accepting PUT, GET, HEAD and DELETE requests, e.g. a WebDAV share or an Artifactory or Nexus raw repository. The sha256 sum of the contents is sent in the `X-Checksum-Sha256` header",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "workspace id and url of the object",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"url": schema.StringAttribute{
+				MarkdownDescription: "base url the key is appended to, e.g. `https://artifacts.example.com/repository/tfstate`",
+				Required:            true,
+				Validators:          httpAddressValidators(),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "path of the object below `url`",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"headers": schema.MapAttribute{
+				MarkdownDescription: "headers sent with every request, e.g. `Authorization`",
+				Optional:            true,
+				Sensitive:           true,
+				ElementType:         types.StringType,
+			},
+			"compression": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("compression of the object contents, one of `%s` or `%s`. Defaults to `%s`", stateCompressionNone, stateCompressionGzip, stateCompressionNone),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(stateCompressionNone, stateCompressionGzip),
+				},
+			},
+			"encryption_key": schema.StringAttribute{
+				MarkdownDescription: "base64 encoded 256-bit key. When set, the object contents are encrypted with AES-256-GCM after compression, prefixed with the nonce",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state",
+				Computed:            true,
+			},
+			"object_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of the object contents after decryption and decompression",
+				Computed:            true,
+			},
+			"ignore_empty": schema.BoolAttribute{
+				MarkdownDescription: "ignore if no state is found",
+				Optional:            true,
+			},
+			"ignored": schema.BoolAttribute{
+				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
+				Computed:            true,
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: "keep the object when the resource is destroyed",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (m *HTTPObjectResourceModel) destination(ctx context.Context, maxRetries int) (dst *httpDestination, diag diag.Diagnostics) {
+	var headers map[string]string
+	diag.Append(m.Headers.ElementsAs(ctx, &headers, true)...)
+	if diag.HasError() {
+		return
+	}
+
+	return newHTTPDestination(m.URL.ValueString(), headers, maxRetries), diag
+}
+
+func (m *HTTPObjectResourceModel) encoding() stateEncoding {
+	return stateEncoding{
+		Compression:   m.Compression.ValueString(),
+		EncryptionKey: m.EncryptionKey.ValueString(),
+	}
+}
+
+func (m *HTTPObjectResourceModel) id() string {
+	return fmt.Sprintf("%s/%s", m.WorkspaceId.ValueString(), m.objectURL())
+}
+
+func (m *HTTPObjectResourceModel) key() string {
+	return m.Key.ValueString()
+}
+
+func (m *HTTPObjectResourceModel) location() string {
+	return fmt.Sprintf("url: %s", m.objectURL())
+}
+
+func (m *HTTPObjectResourceModel) objectURL() string {
+	return httpObjectURL(m.URL.ValueString(), m.Key.ValueString())
+}
+
+func (m *HTTPObjectResourceModel) clear() {
+	m.StateContentsSha256 = types.StringNull()
+	m.ObjectContentsSha256 = types.StringNull()
+}
+
+func (m *HTTPObjectResourceModel) setContents(dst *httpDestination, state []byte, contents []byte) {
+	m.StateContentsSha256 = sha256Contents(state)
+	m.ObjectContentsSha256 = sha256Contents(contents)
+}
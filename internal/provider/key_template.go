@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+const (
+	keyTemplateOrganization  = "{organization}"
+	keyTemplateWorkspaceId   = "{workspace_id}"
+	keyTemplateWorkspaceName = "{workspace_name}"
+	keyTemplateProjectId     = "{project_id}"
+)
+
+var keyTemplateMarkdown = fmt.Sprintf("`%s`, `%s`, `%s` and `%s`", keyTemplateOrganization, keyTemplateWorkspaceId, keyTemplateWorkspaceName, keyTemplateProjectId)
+
+// expandKeyTemplate substitutes the workspace placeholders in template.
+func expandKeyTemplate(template string, organization string, ws *tfe.Workspace) string {
+	var projectId string
+	if ws.Project != nil {
+		projectId = ws.Project.ID
+	}
+
+	return strings.NewReplacer(
+		keyTemplateOrganization, organization,
+		keyTemplateWorkspaceId, ws.ID,
+		keyTemplateWorkspaceName, ws.Name,
+		keyTemplateProjectId, projectId,
+	).Replace(template)
+}
+
+// keyTemplatePatterns matches the values substituted for each placeholder.
+var keyTemplatePatterns = map[string]string{
+	keyTemplateWorkspaceId:   `ws-[a-zA-Z0-9]+`,
+	keyTemplateWorkspaceName: `[a-zA-Z0-9_-]+`,
+	keyTemplateProjectId:     `prj-[a-zA-Z0-9]+`,
+}
+
+// keyTemplateMatch holds the workspace parsed from a key by a keyTemplateParser.
+type keyTemplateMatch struct {
+	WorkspaceId   string
+	WorkspaceName string
+}
+
+// keyTemplateParser is the inverse of expandKeyTemplate.
+type keyTemplateParser struct {
+	re *regexp.Regexp
+}
+
+var keyTemplatePlaceholderRegexp = regexp.MustCompile(`\{[a-z_]+\}`)
+
+func newKeyTemplateParser(template string, organization string) (*keyTemplateParser, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	seen := make(map[string]bool)
+	last := 0
+	for _, loc := range keyTemplatePlaceholderRegexp.FindAllStringIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		last = loc[1]
+
+		placeholder := template[loc[0]:loc[1]]
+		switch pattern, ok := keyTemplatePatterns[placeholder]; {
+		case placeholder == keyTemplateOrganization:
+			b.WriteString(regexp.QuoteMeta(organization))
+		case ok && !seen[placeholder]:
+			seen[placeholder] = true
+			fmt.Fprintf(&b, "(?P<%s>%s)", strings.Trim(placeholder, "{}"), pattern)
+		case ok:
+			fmt.Fprintf(&b, "(?:%s)", pattern)
+		default:
+			b.WriteString(regexp.QuoteMeta(placeholder))
+		}
+	}
+
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid key template %q: %w", template, err)
+	}
+
+	return &keyTemplateParser{re: re}, nil
+}
+
+// parse returns the workspace identified by key, or false if key does not
+// match the template.
+func (p *keyTemplateParser) parse(key string) (keyTemplateMatch, bool) {
+	m := p.re.FindStringSubmatch(key)
+	if m == nil {
+		return keyTemplateMatch{}, false
+	}
+
+	var match keyTemplateMatch
+	if i := p.re.SubexpIndex("workspace_id"); i > 0 {
+		match.WorkspaceId = m[i]
+	}
+	if i := p.re.SubexpIndex("workspace_name"); i > 0 {
+		match.WorkspaceName = m[i]
+	}
+
+	return match, true
+}
+
+var _ validator.String = keyTemplateValidator{}
+
+// keyTemplateValidator requires a key template to identify the workspace, so
+// that no two workspaces are written to the same key.
+type keyTemplateValidator struct{}
+
+func (v keyTemplateValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must contain %s or %s", keyTemplateWorkspaceId, keyTemplateWorkspaceName)
+}
+
+func (v keyTemplateValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v keyTemplateValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	template := req.ConfigValue.ValueString()
+
+	if !strings.Contains(template, keyTemplateWorkspaceId) && !strings.Contains(template, keyTemplateWorkspaceName) {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Key Template", fmt.Sprintf("Attribute %s must contain %s or %s, got: %s", req.Path, keyTemplateWorkspaceId, keyTemplateWorkspaceName, template))
+	}
+}
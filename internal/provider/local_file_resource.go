@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &LocalFileResource{}
+
+const defaultLocalFilePermission = "0600"
+
+var localFilePermissionRegexp = regexp.MustCompile(`^0?[0-7]{3}$`)
+
+func NewLocalFileResource() resource.Resource {
+	return &LocalFileResource{}
+}
+
+type LocalFileResource struct {
+	destinationResource[LocalFileResourceModel, *LocalFileResourceModel, *localDestination]
+}
+
+type LocalFileResourceModel struct {
+	destinationResourceModel
+
+	Path                types.String `tfsdk:"path"`
+	FilePermission      types.String `tfsdk:"file_permission"`
+	Compression         types.String `tfsdk:"compression"`
+	EncryptionKey       types.String `tfsdk:"encryption_key"`
+	StateContentsSha256 types.String `tfsdk:"state_contents_sha256"`
+	FileContentsSha256  types.String `tfsdk:"file_contents_sha256"`
+}
+
+func (r *LocalFileResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_local_file"
+}
+
+func (r *LocalFileResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to sync tf-state to a file on a local or mounted filesystem. The file is written to a temporary file and renamed into place, so readers never see a partial state",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "workspace id and path",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"path": schema.StringAttribute{
+				MarkdownDescription: "path of the file. Missing parent directories are created with mode `0700`",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"file_permission": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("octal file mode, e.g. `0640`. Defaults to `%s`", defaultLocalFilePermission),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(localFilePermissionRegexp, "must be an octal file mode, e.g. 0640"),
+				},
+			},
+			"compression": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("compression of the file contents, one of `%s` or `%s`. Defaults to `%s`", stateCompressionNone, stateCompressionGzip, stateCompressionNone),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(stateCompressionNone, stateCompressionGzip),
+				},
+			},
+			"encryption_key": schema.StringAttribute{
+				MarkdownDescription: "base64 encoded 256-bit key. When set, the file contents are encrypted with AES-256-GCM after compression, prefixed with the nonce",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state",
+				Computed:            true,
+			},
+			"file_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of the file contents after decryption and decompression",
+				Computed:            true,
+			},
+			"ignore_empty": schema.BoolAttribute{
+				MarkdownDescription: "ignore if no state is found",
+				Optional:            true,
+			},
+			"ignored": schema.BoolAttribute{
+				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
+				Computed:            true,
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: "keep the file when the resource is destroyed",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (m *LocalFileResourceModel) destination(ctx context.Context, maxRetries int) (dst *localDestination, diag diag.Diagnostics) {
+	permission := defaultLocalFilePermission
+	if !m.FilePermission.IsNull() {
+		permission = m.FilePermission.ValueString()
+	}
+
+	mode, err := strconv.ParseUint(permission, 8, 32)
+	if err != nil {
+		diag.AddError("local file", fmt.Sprintf("invalid file_permission: %s", err))
+		return
+	}
+
+	return newLocalDestination(fs.FileMode(mode)), diag
+}
+
+func (m *LocalFileResourceModel) encoding() stateEncoding {
+	return stateEncoding{
+		Compression:   m.Compression.ValueString(),
+		EncryptionKey: m.EncryptionKey.ValueString(),
+	}
+}
+
+func (m *LocalFileResourceModel) id() string {
+	return fmt.Sprintf("%s/%s", m.WorkspaceId.ValueString(), m.Path.ValueString())
+}
+
+func (m *LocalFileResourceModel) key() string {
+	return m.Path.ValueString()
+}
+
+func (m *LocalFileResourceModel) location() string {
+	return fmt.Sprintf("path: %s", m.Path.ValueString())
+}
+
+func (m *LocalFileResourceModel) clear() {
+	m.StateContentsSha256 = types.StringNull()
+	m.FileContentsSha256 = types.StringNull()
+}
+
+func (m *LocalFileResourceModel) setContents(dst *localDestination, state []byte, contents []byte) {
+	m.StateContentsSha256 = sha256Contents(state)
+	m.FileContentsSha256 = sha256Contents(contents)
+}
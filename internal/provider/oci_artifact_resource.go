@@ -0,0 +1,198 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &OCIArtifactResource{}
+
+func NewOCIArtifactResource() resource.Resource {
+	return &OCIArtifactResource{}
+}
+
+type OCIArtifactResource struct {
+	destinationResource[OCIArtifactResourceModel, *OCIArtifactResourceModel, *ociDestination]
+}
+
+type OCIArtifactResourceModel struct {
+	destinationResourceModel
+
+	Repository     types.String `tfsdk:"repository"`
+	Username       types.String `tfsdk:"username"`
+	Password       types.String `tfsdk:"password"`
+	Insecure       types.Bool   `tfsdk:"insecure"`
+	Serial         types.Int64  `tfsdk:"serial"`
+	ManifestDigest types.String `tfsdk:"manifest_digest"`
+	StateDigest    types.String `tfsdk:"state_digest"`
+	ArtifactDigest types.String `tfsdk:"artifact_digest"`
+}
+
+func (r *OCIArtifactResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_oci_artifact"
+}
+
+func (r *OCIArtifactResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: fmt.Sprintf("Resource to push tf-state as an artifact of type `%s` to an OCI-distribution registry. Each state is tagged with its serial and `%s`, and annotated with the workspace, serial and lineage", ociStateArtifactType, ociLatestTag),
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "workspace id and repository",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"repository": schema.StringAttribute{
+				MarkdownDescription: "registry host followed by the repository name, e.g. `registry.example.com/backups/network`",
+				Required:            true,
+				Validators:          ociRepositoryValidators(),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"username": schema.StringAttribute{
+				MarkdownDescription: "registry username",
+				Optional:            true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "registry password or token",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"insecure": schema.BoolAttribute{
+				MarkdownDescription: "talk plain http to the registry",
+				Optional:            true,
+			},
+			"serial": schema.Int64Attribute{
+				MarkdownDescription: "serial of the pushed state, also its tag",
+				Computed:            true,
+			},
+			"manifest_digest": schema.StringAttribute{
+				MarkdownDescription: "digest of the pushed manifest",
+				Computed:            true,
+			},
+			"state_digest": schema.StringAttribute{
+				MarkdownDescription: "digest of tf state",
+				Computed:            true,
+			},
+			"artifact_digest": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("digest of the state tagged `%s` in the registry", ociLatestTag),
+				Computed:            true,
+			},
+			"ignore_empty": schema.BoolAttribute{
+				MarkdownDescription: "ignore if no state is found",
+				Optional:            true,
+			},
+			"ignored": schema.BoolAttribute{
+				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
+				Computed:            true,
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: fmt.Sprintf("keep the artifact when the resource is destroyed. Otherwise the manifest tagged `%s` is deleted, artifacts of earlier serials are kept either way", ociLatestTag),
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (m *OCIArtifactResourceModel) destination(ctx context.Context, maxRetries int) (*ociDestination, diag.Diagnostics) {
+	return newOCIDestination(ociOptions{
+		Repository: m.Repository.ValueString(),
+		Username:   m.Username.ValueString(),
+		Password:   m.Password.ValueString(),
+		Insecure:   m.Insecure.ValueBool(),
+	}, maxRetries)
+}
+
+// resolve takes nothing from the workspace; implementing it makes a sync
+// annotate the artifact with the workspace and state version.
+func (m *OCIArtifactResourceModel) resolve(ws *tfe.Workspace) {}
+
+func (m *OCIArtifactResourceModel) id() string {
+	return fmt.Sprintf("%s/%s", m.WorkspaceId.ValueString(), m.Repository.ValueString())
+}
+
+func (m *OCIArtifactResourceModel) key() string {
+	return ociLatestTag
+}
+
+func (m *OCIArtifactResourceModel) location() string {
+	return fmt.Sprintf("repository: %s", m.Repository.ValueString())
+}
+
+func (m *OCIArtifactResourceModel) clear() {
+	m.Serial = types.Int64Null()
+	m.ManifestDigest = types.StringNull()
+	m.StateDigest = types.StringNull()
+	m.ArtifactDigest = types.StringNull()
+}
+
+func (m *OCIArtifactResourceModel) setContents(dst *ociDestination, state []byte, contents []byte) {
+	m.StateDigest = types.StringValue("sha256:" + sha256Hex(state))
+	m.ArtifactDigest = types.StringValue("sha256:" + sha256Hex(contents))
+}
+
+// write pushes the state tagged with its serial, then tags it as latest.
+func (m *OCIArtifactResourceModel) write(ctx context.Context, dst *ociDestination, ver *tfe.StateVersion, state []byte, metadata destinationMetadata) (diag diag.Diagnostics) {
+	tag := strconv.FormatInt(ver.Serial, 10)
+
+	_, d := putState(ctx, dst, tag, stateEncoding{}, state, metadata)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	digest, d := dst.tag(ctx, tag, ociLatestTag)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	m.Serial = types.Int64Value(ver.Serial)
+	m.ManifestDigest = types.StringValue(digest)
+
+	return
+}
+
+// read only reads the manifest: the digest of its layer is the digest of
+// the state it holds.
+func (m *OCIArtifactResourceModel) read(ctx context.Context, dst *ociDestination, state []byte) (found bool, diag diag.Diagnostics) {
+	obj, d := dst.head(ctx, ociLatestTag)
+	diag.Append(d...)
+	if diag.HasError() || obj == nil {
+		return
+	}
+
+	m.StateDigest = types.StringValue("sha256:" + sha256Hex(state))
+	m.ArtifactDigest = types.StringValue("sha256:" + obj.Sha256)
+
+	return true, diag
+}
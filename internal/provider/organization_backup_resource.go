@@ -0,0 +1,667 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultOrganizationBackupConcurrency = 4
+
+	defaultOrganizationBackupCreateTimeout = 60 * time.Minute
+	defaultOrganizationBackupUpdateTimeout = 60 * time.Minute
+
+	backupStatusSynced    = "synced"
+	backupStatusUnchanged = "unchanged"
+	backupStatusIgnored   = "ignored"
+	backupStatusFailed    = "failed"
+	backupStatusOutdated  = "outdated"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &OrganizationBackupResource{}
+var _ resource.ResourceWithModifyPlan = &OrganizationBackupResource{}
+
+func NewOrganizationBackupResource() resource.Resource {
+	return &OrganizationBackupResource{}
+}
+
+type OrganizationBackupResource struct {
+	softDelete bool
+	tfeClient  *tfe.Client
+	s3Clients  *s3ClientCache
+	stateCache *stateCache
+	uploads    semaphore
+}
+
+type OrganizationBackupResourceModel struct {
+	Id                   types.String   `tfsdk:"id"`
+	Organization         types.String   `tfsdk:"organization"`
+	WorkspaceName        types.String   `tfsdk:"workspace_name"`
+	WorkspaceTags        types.List     `tfsdk:"workspace_tags"`
+	ExcludeWorkspaceTags types.List     `tfsdk:"exclude_workspace_tags"`
+	ProjectId            types.String   `tfsdk:"project_id"`
+	Bucket               types.String   `tfsdk:"bucket"`
+	KeyTemplate          types.String   `tfsdk:"key_template"`
+	KmsKeyId             types.String   `tfsdk:"kms_key_id"`
+	SoftDelete           types.Bool     `tfsdk:"soft_delete"`
+	Tags                 types.Map      `tfsdk:"tags"`
+	Concurrency          types.Int64    `tfsdk:"concurrency"`
+	FailurePolicy        types.String   `tfsdk:"failure_policy"`
+	Results              types.Map      `tfsdk:"results"`
+	Failures             types.List     `tfsdk:"failures"`
+	Timeouts             timeouts.Value `tfsdk:"timeouts"`
+}
+
+type organizationBackupResultModel struct {
+	WorkspaceId         types.String `tfsdk:"workspace_id"`
+	Key                 types.String `tfsdk:"key"`
+	Status              types.String `tfsdk:"status"`
+	StateVersionId      types.String `tfsdk:"state_version_id"`
+	StateContentsSha256 types.String `tfsdk:"state_contents_sha256"`
+}
+
+var organizationBackupResultAttrTypes = map[string]attr.Type{
+	"workspace_id":          types.StringType,
+	"key":                   types.StringType,
+	"status":                types.StringType,
+	"state_version_id":      types.StringType,
+	"state_contents_sha256": types.StringType,
+}
+
+func (r *OrganizationBackupResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_organization_backup"
+}
+
+func (r *OrganizationBackupResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to sync the tf-state of every matching workspace in an organization to s3 objects",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "organization name",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"organization": schema.StringAttribute{
+				MarkdownDescription: "terraform organization",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"workspace_name": schema.StringAttribute{
+				MarkdownDescription: "only sync workspaces matching this name. `*` matches any characters, e.g. `prod-*`",
+				Optional:            true,
+			},
+			"workspace_tags": schema.ListAttribute{
+				MarkdownDescription: "only sync workspaces with all of these tags",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"exclude_workspace_tags": schema.ListAttribute{
+				MarkdownDescription: "do not sync workspaces with any of these tags",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"project_id": schema.StringAttribute{
+				MarkdownDescription: "only sync workspaces in this project",
+				Optional:            true,
+			},
+			"bucket": schema.StringAttribute{
+				MarkdownDescription: "s3 bucket",
+				Required:            true,
+				Validators:          s3BucketValidators(),
+			},
+			"key_template": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("s3 bucket key for each workspace. Supports the placeholders %s, e.g. `statefiles/{workspace_name}/terraform.tfstate`", keyTemplateMarkdown),
+				Required:            true,
+				Validators: []validator.String{
+					keyTemplateValidator{},
+				},
+			},
+			"kms_key_id": schema.StringAttribute{
+				MarkdownDescription: "kms key id",
+				Optional:            true,
+				Validators:          kmsKeyIdValidators(),
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: "use soft delete",
+				Optional:            true,
+			},
+			"tags": schema.MapAttribute{
+				MarkdownDescription: "A map of tags to apply to all objects.",
+				Optional:            true,
+				ElementType:         types.StringType,
+				Validators:          s3TagsValidators(),
+			},
+			"concurrency": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("number of workspaces synced at once. Defaults to `%d`", defaultOrganizationBackupConcurrency),
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"failure_policy": failurePolicyAttribute(),
+			"failures":       failuresAttribute(),
+			"results": schema.MapNestedAttribute{
+				MarkdownDescription: fmt.Sprintf("result of the last sync by workspace name. `status` is one of `%s`, `%s`, `%s` or `%s`, or `%s` when a refresh finds the workspace state has changed since", backupStatusSynced, backupStatusUnchanged, backupStatusIgnored, backupStatusFailed, backupStatusOutdated),
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"workspace_id": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace id",
+							Computed:            true,
+						},
+						"key": schema.StringAttribute{
+							MarkdownDescription: "s3 bucket key",
+							Computed:            true,
+						},
+						"status": schema.StringAttribute{
+							MarkdownDescription: "sync status",
+							Computed:            true,
+						},
+						"state_version_id": schema.StringAttribute{
+							MarkdownDescription: "id of the synced state version",
+							Computed:            true,
+						},
+						"state_contents_sha256": schema.StringAttribute{
+							MarkdownDescription: "sha256 sum of the synced tf state",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (r *OrganizationBackupResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.softDelete = data.softDelete
+	r.tfeClient = data.tfeClient
+	r.s3Clients = data.s3Clients
+	r.stateCache = data.stateCache
+	r.uploads = data.uploads
+}
+
+// ModifyPlan schedules an update when a refresh found workspaces that need
+// syncing, since results is computed and would otherwise never differ from
+// the configuration.
+func (r *OrganizationBackupResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var state OrganizationBackupResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	results, d := organizationBackupResults(ctx, state.Results)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, result := range results {
+		switch result.Status.ValueString() {
+		case backupStatusOutdated, backupStatusFailed:
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("results"), types.MapUnknown(types.ObjectType{AttrTypes: organizationBackupResultAttrTypes}))...)
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("failures"), types.ListUnknown(types.ObjectType{AttrTypes: syncFailureAttrTypes}))...)
+			return
+		}
+	}
+}
+
+func (r *OrganizationBackupResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	resp.Diagnostics.Append(validateOrganizationBackupResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data OrganizationBackupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := data.Timeouts.Create(ctx, defaultOrganizationBackupCreateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	data.Id = data.Organization
+
+	results, failures, d := r.sync(ctx, &data, nil)
+	resp.Diagnostics.Append(d...)
+
+	data.Results, d = types.MapValueFrom(ctx, types.ObjectType{AttrTypes: organizationBackupResultAttrTypes}, results)
+	resp.Diagnostics.Append(d...)
+
+	data.Failures, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: syncFailureAttrTypes}, failures)
+	resp.Diagnostics.Append(d...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OrganizationBackupResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	resp.Diagnostics.Append(validateOrganizationBackupResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data OrganizationBackupResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := data.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	prior, d := organizationBackupResults(ctx, data.Results)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	workspaces, d := r.listWorkspaces(ctx, &data)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only the workspace list is read here, the state of each workspace is
+	// compared by state version id so that a refresh costs a handful of
+	// requests rather than one download per workspace.
+	results := make(map[string]organizationBackupResultModel, len(workspaces))
+	for _, ws := range workspaces {
+		result, ok := prior[ws.Name]
+		key := expandKeyTemplate(data.KeyTemplate.ValueString(), data.Organization.ValueString(), ws)
+		svId := currentStateVersionId(ws)
+
+		switch {
+		case !ok || result.WorkspaceId.ValueString() != ws.ID || result.Key.ValueString() != key:
+			result = newOrganizationBackupResult(ws, key, backupStatusOutdated)
+		case svId == "" && result.Status.ValueString() == backupStatusIgnored:
+		case svId != result.StateVersionId.ValueString():
+			result.Status = types.StringValue(backupStatusOutdated)
+		}
+
+		results[ws.Name] = result
+	}
+
+	data.Results, d = types.MapValueFrom(ctx, types.ObjectType{AttrTypes: organizationBackupResultAttrTypes}, results)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OrganizationBackupResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	resp.Diagnostics.Append(validateOrganizationBackupResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan, state OrganizationBackupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := plan.Timeouts.Update(ctx, defaultOrganizationBackupUpdateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Objects are only left as they are when they would be written the same
+	// way, otherwise every workspace is synced again.
+	var prior map[string]organizationBackupResultModel
+	if plan.Bucket.Equal(state.Bucket) && plan.KmsKeyId.Equal(state.KmsKeyId) && plan.Tags.Equal(state.Tags) {
+		prior, d = organizationBackupResults(ctx, state.Results)
+		resp.Diagnostics.Append(d...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	results, failures, d := r.sync(ctx, &plan, prior)
+	resp.Diagnostics.Append(d...)
+
+	plan.Results, d = types.MapValueFrom(ctx, types.ObjectType{AttrTypes: organizationBackupResultAttrTypes}, results)
+	resp.Diagnostics.Append(d...)
+
+	plan.Failures, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: syncFailureAttrTypes}, failures)
+	resp.Diagnostics.Append(d...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *OrganizationBackupResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	resp.Diagnostics.Append(validateOrganizationBackupResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data OrganizationBackupResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := data.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if r.softDelete || data.SoftDelete.ValueBool() {
+		resp.Diagnostics.AddWarning("using soft delete", fmt.Sprintf("bucket: %s, key template: %s", data.Bucket.ValueString(), data.KeyTemplate.ValueString()))
+		return
+	}
+
+	results, d := organizationBackupResults(ctx, data.Results)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dst, d := r.destination(ctx, &data)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(results)) {
+		result := results[name]
+		if result.StateContentsSha256.IsNull() {
+			continue
+		}
+
+		resp.Diagnostics.Append(dst.delete(ctx, result.Key.ValueString())...)
+	}
+}
+
+// sync uploads the state of every matching workspace, skipping workspaces
+// whose state version is unchanged since prior. A workspace that fails is
+// recorded in failures and, unless the failure policy is fail_fast, does not
+// stop the remaining workspaces from syncing.
+func (r *OrganizationBackupResource) sync(ctx context.Context, data *OrganizationBackupResourceModel, prior map[string]organizationBackupResultModel) (results map[string]organizationBackupResultModel, failures []syncFailureModel, diag diag.Diagnostics) {
+	failures = []syncFailureModel{}
+
+	var tags map[string]string
+	diag.Append(data.Tags.ElementsAs(ctx, &tags, true)...)
+	if diag.HasError() {
+		return
+	}
+
+	dst, d := r.destination(ctx, data)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	workspaces, d := r.listWorkspaces(ctx, data)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	concurrency := int64(defaultOrganizationBackupConcurrency)
+	if !data.Concurrency.IsNull() {
+		concurrency = data.Concurrency.ValueInt64()
+	}
+
+	policy := failurePolicy(data.FailurePolicy)
+	results = make(map[string]organizationBackupResultModel, len(workspaces))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := newSemaphore(concurrency)
+
+	for _, ws := range workspaces {
+		if err := sem.acquire(ctx); err != nil {
+			diag.AddError("organization backup", fmt.Sprintf("failed waiting to sync workspace %s: %s", ws.Name, err))
+			break
+		}
+
+		mu.Lock()
+		stop := policy == failurePolicyFailFast && len(failures) > 0
+		mu.Unlock()
+
+		if stop {
+			sem.release()
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sem.release()
+
+			var p *organizationBackupResultModel
+			if result, ok := prior[ws.Name]; ok {
+				p = &result
+			}
+
+			result, failure := r.syncWorkspace(ctx, data, dst, ws, tags, p)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[ws.Name] = result
+			if failure != nil {
+				failures = append(failures, *failure)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// Workspaces skipped after a failure keep their previous result, the
+	// next refresh marks them outdated if they have changed since.
+	for _, ws := range workspaces {
+		if _, ok := results[ws.Name]; ok {
+			continue
+		}
+		if p, ok := prior[ws.Name]; ok {
+			results[ws.Name] = p
+		}
+	}
+
+	slices.SortFunc(failures, func(a, b syncFailureModel) int {
+		return strings.Compare(a.Workspace.ValueString(), b.Workspace.ValueString())
+	})
+
+	diag.Append(reportSyncFailures(policy, failures, len(workspaces))...)
+
+	return
+}
+
+func (r *OrganizationBackupResource) syncWorkspace(ctx context.Context, data *OrganizationBackupResourceModel, dst destination, ws *tfe.Workspace, tags map[string]string, prior *organizationBackupResultModel) (result organizationBackupResultModel, failure *syncFailureModel) {
+	ctx = tflog.SetField(ctx, "workspace_id", ws.ID)
+
+	key := expandKeyTemplate(data.KeyTemplate.ValueString(), data.Organization.ValueString(), ws)
+	svId := currentStateVersionId(ws)
+
+	if svId == "" {
+		return newOrganizationBackupResult(ws, key, backupStatusIgnored), nil
+	}
+
+	if prior != nil && prior.Key.ValueString() == key && prior.StateVersionId.ValueString() == svId {
+		switch prior.Status.ValueString() {
+		case backupStatusSynced, backupStatusUnchanged:
+			result = *prior
+			result.Status = types.StringValue(backupStatusUnchanged)
+			return
+		}
+	}
+
+	result = newOrganizationBackupResult(ws, key, backupStatusFailed)
+
+	// Download the state version the workspace was listed with rather than
+	// the current one, which may have changed since and would be recorded
+	// under svId.
+	ver, err := r.tfeClient.StateVersions.Read(ctx, svId)
+	if err != nil {
+		var d diag.Diagnostics
+		d.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", err))
+		failure = newSyncFailure(ws.Name, syncStageDownload, d)
+		return
+	}
+
+	state, err := downloadStateVersion(ctx, r.tfeClient, r.stateCache, ver)
+	if err != nil {
+		var d diag.Diagnostics
+		d.AddError("tfe client", fmt.Sprintf("failed to download state: %s", err))
+		failure = newSyncFailure(ws.Name, syncStageDownload, d)
+		return
+	}
+
+	stage, d := putState(ctx, dst, key, stateEncoding{}, state, destinationMetadata{WorkspaceId: ws.ID, Tags: tags})
+	if d.HasError() {
+		failure = newSyncFailure(ws.Name, stage, d)
+		return
+	}
+
+	result.Status = types.StringValue(backupStatusSynced)
+	result.StateVersionId = types.StringValue(svId)
+	result.StateContentsSha256 = sha256Contents(state)
+
+	return
+}
+
+func (r *OrganizationBackupResource) destination(ctx context.Context, data *OrganizationBackupResourceModel) (dst destination, diag diag.Diagnostics) {
+	client, err := r.s3Clients.forBucket(ctx, data.Bucket.ValueString(), s3ClientOptions{})
+	if err != nil {
+		diag.AddError("s3 client", err.Error())
+		return
+	}
+
+	return newS3Destination(client, data.Bucket.ValueString(), data.KmsKeyId.ValueString(), r.uploads), diag
+}
+
+func (r *OrganizationBackupResource) listWorkspaces(ctx context.Context, data *OrganizationBackupResourceModel) (workspaces []*tfe.Workspace, diag diag.Diagnostics) {
+	var filter workspaceFilter
+	filter.Name = data.WorkspaceName.ValueString()
+	filter.ProjectId = data.ProjectId.ValueString()
+	diag.Append(data.WorkspaceTags.ElementsAs(ctx, &filter.Tags, true)...)
+	diag.Append(data.ExcludeWorkspaceTags.ElementsAs(ctx, &filter.ExcludeTags, true)...)
+	if diag.HasError() {
+		return
+	}
+
+	workspaces, err := listWorkspaces(ctx, r.tfeClient, data.Organization.ValueString(), filter)
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to list workspaces: %s", err))
+		return
+	}
+
+	return
+}
+
+func newOrganizationBackupResult(ws *tfe.Workspace, key string, status string) organizationBackupResultModel {
+	return organizationBackupResultModel{
+		WorkspaceId:         types.StringValue(ws.ID),
+		Key:                 types.StringValue(key),
+		Status:              types.StringValue(status),
+		StateVersionId:      types.StringNull(),
+		StateContentsSha256: types.StringNull(),
+	}
+}
+
+func organizationBackupResults(ctx context.Context, m types.Map) (results map[string]organizationBackupResultModel, diag diag.Diagnostics) {
+	if m.IsNull() || m.IsUnknown() {
+		return
+	}
+
+	diag.Append(m.ElementsAs(ctx, &results, false)...)
+
+	return
+}
+
+func validateOrganizationBackupResource(r *OrganizationBackupResource) (diag diag.Diagnostics) {
+	if r == nil {
+		diag.AddError("provider", "nil receiver")
+		return
+	}
+
+	if r.s3Clients == nil {
+		diag.AddError("provider", "nil s3 client cache")
+		return
+	}
+
+	if r.tfeClient == nil {
+		diag.AddError("provider", "nil tfe client")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestOrganizationBackupFailurePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy   string
+		statuses map[string]string
+		warning  bool
+	}{
+		{
+			policy:   failurePolicyContinueAndError,
+			statuses: map[string]string{"a": backupStatusSynced, "b": backupStatusFailed, "c": backupStatusSynced, "d": backupStatusIgnored},
+		},
+		{
+			policy:   failurePolicyContinueAndWarn,
+			statuses: map[string]string{"a": backupStatusSynced, "b": backupStatusFailed, "c": backupStatusSynced, "d": backupStatusIgnored},
+			warning:  true,
+		},
+		{
+			// Workspaces are synced one at a time, so nothing after b starts.
+			policy:   failurePolicyFailFast,
+			statuses: map[string]string{"a": backupStatusSynced, "b": backupStatusFailed},
+		},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			f, client := newFakeTfeStates(t, "a", "b", "c", "d")
+			for _, name := range []string{"a", "b", "c"} {
+				f.addState(name, fakeTfeState)
+			}
+			f.failDownload["b"] = true
+
+			objects := &fakeS3Objects{objects: make(map[string][]byte)}
+			base, s3Client := newFakeS3Client(t, objects)
+
+			r := &OrganizationBackupResource{
+				tfeClient:  client,
+				s3Clients:  newS3ClientCache(base, s3Client),
+				stateCache: newStateCache(1<<20, ""),
+				uploads:    newSemaphore(1),
+			}
+			data := &OrganizationBackupResourceModel{
+				Organization:         types.StringValue("acme"),
+				WorkspaceTags:        types.ListNull(types.StringType),
+				ExcludeWorkspaceTags: types.ListNull(types.StringType),
+				Bucket:               types.StringValue("backups"),
+				KeyTemplate:          types.StringValue("{organization}/{workspace_name}"),
+				Tags:                 types.MapNull(types.StringType),
+				Concurrency:          types.Int64Value(1),
+				FailurePolicy:        types.StringValue(tc.policy),
+			}
+
+			results, failures, diags := r.sync(context.Background(), data, nil)
+			warned := len(diags.Warnings()) > 0
+			if diags.HasError() == tc.warning || warned != tc.warning {
+				t.Errorf("got %v, want warning %t", diags, tc.warning)
+			}
+
+			statuses := make(map[string]string)
+			for name, result := range results {
+				statuses[name] = result.Status.ValueString()
+			}
+			if !maps.Equal(statuses, tc.statuses) {
+				t.Errorf("got statuses %v, want %v", statuses, tc.statuses)
+			}
+
+			if len(failures) != 1 || failures[0].Workspace.ValueString() != "b" || failures[0].Stage.ValueString() != syncStageDownload {
+				t.Errorf("got failures %v, want the download of b", failures)
+			}
+
+			var written []string
+			for name, status := range tc.statuses {
+				if status == backupStatusSynced {
+					written = append(written, "/backups/acme/"+name)
+				}
+			}
+			slices.Sort(written)
+			if got := slices.Sorted(maps.Keys(objects.objects)); !slices.Equal(got, written) {
+				t.Errorf("got objects %q, want %q", got, written)
+			}
+		})
+	}
+}
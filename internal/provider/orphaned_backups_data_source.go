@@ -0,0 +1,402 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// orphanedBackupsHeadConcurrency bounds the number of objects whose metadata
+// is read at once when no key template is given.
+const orphanedBackupsHeadConcurrency = 8
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &OrphanedBackupsDataSource{}
+
+func NewOrphanedBackupsDataSource() datasource.DataSource {
+	return &OrphanedBackupsDataSource{}
+}
+
+type OrphanedBackupsDataSource struct {
+	tfeClient *tfe.Client
+	s3Clients *s3ClientCache
+}
+
+type OrphanedBackupsDataSourceModel struct {
+	Id           types.String `tfsdk:"id"`
+	Organization types.String `tfsdk:"organization"`
+	Bucket       types.String `tfsdk:"bucket"`
+	Prefix       types.String `tfsdk:"prefix"`
+	KeyTemplate  types.String `tfsdk:"key_template"`
+	Orphans      types.List   `tfsdk:"orphans"`
+	Missing      types.List   `tfsdk:"missing"`
+	Duplicates   types.List   `tfsdk:"duplicates"`
+	UnknownKeys  types.List   `tfsdk:"unknown_keys"`
+}
+
+type orphanedBackupModel struct {
+	Key           types.String `tfsdk:"key"`
+	WorkspaceId   types.String `tfsdk:"workspace_id"`
+	WorkspaceName types.String `tfsdk:"workspace_name"`
+}
+
+var orphanedBackupAttrTypes = map[string]attr.Type{
+	"key":            types.StringType,
+	"workspace_id":   types.StringType,
+	"workspace_name": types.StringType,
+}
+
+type missingBackupModel struct {
+	WorkspaceId   types.String `tfsdk:"workspace_id"`
+	WorkspaceName types.String `tfsdk:"workspace_name"`
+}
+
+var missingBackupAttrTypes = map[string]attr.Type{
+	"workspace_id":   types.StringType,
+	"workspace_name": types.StringType,
+}
+
+type duplicateBackupModel struct {
+	WorkspaceId   types.String `tfsdk:"workspace_id"`
+	WorkspaceName types.String `tfsdk:"workspace_name"`
+	Keys          types.List   `tfsdk:"keys"`
+}
+
+var duplicateBackupAttrTypes = map[string]attr.Type{
+	"workspace_id":   types.StringType,
+	"workspace_name": types.StringType,
+	"keys":           types.ListType{ElemType: types.StringType},
+}
+
+func (d *OrphanedBackupsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_orphaned_backups"
+}
+
+func (d *OrphanedBackupsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Data source to cross-reference the backups under a bucket prefix with the workspaces of an organization, to find backups of deleted workspaces, workspaces without a backup and workspaces with more than one backup",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "organization, bucket and prefix",
+				Computed:            true,
+			},
+			"organization": schema.StringAttribute{
+				MarkdownDescription: "terraform organization",
+				Required:            true,
+			},
+			"bucket": schema.StringAttribute{
+				MarkdownDescription: "s3 bucket",
+				Required:            true,
+				Validators:          s3BucketValidators(),
+			},
+			"prefix": schema.StringAttribute{
+				MarkdownDescription: "only objects with keys beginning with prefix are considered",
+				Optional:            true,
+			},
+			"key_template": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("template the backup keys were written with, see `tfsync_organization_backup`. Supports %s. When not set, the workspace is read from the object metadata written by the provider", keyTemplateMarkdown),
+				Optional:            true,
+				Validators: []validator.String{
+					keyTemplateValidator{},
+				},
+			},
+			"orphans": schema.ListNestedAttribute{
+				MarkdownDescription: "objects whose workspace no longer exists",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"key": schema.StringAttribute{
+							MarkdownDescription: "s3 bucket key",
+							Computed:            true,
+						},
+						"workspace_id": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace id, null if only the name is known",
+							Computed:            true,
+						},
+						"workspace_name": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace name, null if only the id is known",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"missing": schema.ListNestedAttribute{
+				MarkdownDescription: "workspaces with state but no object",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"workspace_id": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace id",
+							Computed:            true,
+						},
+						"workspace_name": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace name",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"duplicates": schema.ListNestedAttribute{
+				MarkdownDescription: "workspaces claimed by more than one object",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"workspace_id": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace id",
+							Computed:            true,
+						},
+						"workspace_name": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace name",
+							Computed:            true,
+						},
+						"keys": schema.ListAttribute{
+							MarkdownDescription: "s3 bucket keys of the objects",
+							Computed:            true,
+							ElementType:         types.StringType,
+						},
+					},
+				},
+			},
+			"unknown_keys": schema.ListAttribute{
+				MarkdownDescription: "keys of objects that match neither `key_template` nor carry workspace metadata",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+		},
+	}
+}
+
+func (d *OrphanedBackupsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.tfeClient = data.tfeClient
+	d.s3Clients = data.s3Clients
+}
+
+func (d *OrphanedBackupsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.tfeClient == nil || d.s3Clients == nil {
+		resp.Diagnostics.AddError("provider", "provider is not configured")
+		return
+	}
+
+	var data OrphanedBackupsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	organization, bucket, prefix := data.Organization.ValueString(), data.Bucket.ValueString(), data.Prefix.ValueString()
+
+	workspaces, err := listWorkspaces(ctx, d.tfeClient, organization, workspaceFilter{})
+	if err != nil {
+		resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to list workspaces: %s", err))
+		return
+	}
+
+	client, err := d.s3Clients.forBucket(ctx, bucket, s3ClientOptions{})
+	if err != nil {
+		resp.Diagnostics.AddError("s3 client", err.Error())
+		return
+	}
+
+	keys, diags := listS3Keys(ctx, client, bucket, prefix)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var matches map[string]keyTemplateMatch
+	if !data.KeyTemplate.IsNull() {
+		parser, err := newKeyTemplateParser(data.KeyTemplate.ValueString(), organization)
+		if err != nil {
+			resp.Diagnostics.AddError("key template", err.Error())
+			return
+		}
+
+		matches = make(map[string]keyTemplateMatch)
+		for _, key := range keys {
+			if m, ok := parser.parse(key); ok {
+				matches[key] = m
+			}
+		}
+	} else {
+		keys, matches, diags = readS3WorkspaceMetadata(ctx, client, bucket, keys)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	byId := make(map[string]*tfe.Workspace, len(workspaces))
+	byName := make(map[string]*tfe.Workspace, len(workspaces))
+	for _, ws := range workspaces {
+		byId[ws.ID] = ws
+		byName[ws.Name] = ws
+	}
+
+	orphans := []orphanedBackupModel{}
+	unknownKeys := []string{}
+	claims := make(map[string][]string)
+	for _, key := range keys {
+		m, ok := matches[key]
+		if !ok {
+			unknownKeys = append(unknownKeys, key)
+			continue
+		}
+
+		ws := byId[m.WorkspaceId]
+		if m.WorkspaceId == "" {
+			ws = byName[m.WorkspaceName]
+		}
+		if ws == nil {
+			orphans = append(orphans, orphanedBackupModel{
+				Key:           types.StringValue(key),
+				WorkspaceId:   stringValueOrNull(m.WorkspaceId),
+				WorkspaceName: stringValueOrNull(m.WorkspaceName),
+			})
+			continue
+		}
+
+		claims[ws.ID] = append(claims[ws.ID], key)
+	}
+
+	missing := []missingBackupModel{}
+	duplicates := []duplicateBackupModel{}
+	for _, ws := range workspaces {
+		switch claimed := claims[ws.ID]; {
+		case len(claimed) == 0 && currentStateVersionId(ws) != "":
+			missing = append(missing, missingBackupModel{
+				WorkspaceId:   types.StringValue(ws.ID),
+				WorkspaceName: types.StringValue(ws.Name),
+			})
+		case len(claimed) > 1:
+			claimedKeys, diags := types.ListValueFrom(ctx, types.StringType, claimed)
+			resp.Diagnostics.Append(diags...)
+			duplicates = append(duplicates, duplicateBackupModel{
+				WorkspaceId:   types.StringValue(ws.ID),
+				WorkspaceName: types.StringValue(ws.Name),
+				Keys:          claimedKeys,
+			})
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].WorkspaceName.ValueString() < missing[j].WorkspaceName.ValueString()
+	})
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].WorkspaceName.ValueString() < duplicates[j].WorkspaceName.ValueString()
+	})
+
+	data.Id = types.StringValue(fmt.Sprintf("%s/%s/%s", organization, bucket, prefix))
+
+	data.Orphans, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: orphanedBackupAttrTypes}, orphans)
+	resp.Diagnostics.Append(diags...)
+
+	data.Missing, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: missingBackupAttrTypes}, missing)
+	resp.Diagnostics.Append(diags...)
+
+	data.Duplicates, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: duplicateBackupAttrTypes}, duplicates)
+	resp.Diagnostics.Append(diags...)
+
+	data.UnknownKeys, diags = types.ListValueFrom(ctx, types.StringType, unknownKeys)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// readS3WorkspaceMetadata returns the workspace recorded in the metadata of
+// each object by putS3ObjectContents. Objects without metadata are omitted
+// from matches, objects deleted since they were listed from found.
+func readS3WorkspaceMetadata(ctx context.Context, client *s3.Client, bucket string, keys []string) (found []string, matches map[string]keyTemplateMatch, diag diag.Diagnostics) {
+	matches = make(map[string]keyTemplateMatch)
+	deleted := make(map[string]bool)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := newSemaphore(orphanedBackupsHeadConcurrency)
+	for _, key := range keys {
+		if err := sem.acquire(ctx); err != nil {
+			mu.Lock()
+			diag.AddError("s3 client", fmt.Sprintf("failed waiting to read object metadata: %s", err))
+			mu.Unlock()
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sem.release()
+
+			out, err := client.HeadObject(ctx, &s3.HeadObjectInput{
+				Bucket: aws.String(bucket),
+				Key:    aws.String(key),
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				// The object was deleted since it was listed.
+				var notFound *s3types.NotFound
+				if errors.As(err, &notFound) {
+					deleted[key] = true
+					return
+				}
+
+				diag.AddError("s3 client", fmt.Sprintf("failed to read object metadata of %s: %s", key, err))
+				return
+			}
+			if id := out.Metadata[s3MetadataWorkspaceId]; id != "" {
+				matches[key] = keyTemplateMatch{WorkspaceId: id}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, key := range keys {
+		if !deleted[key] {
+			found = append(found, key)
+		}
+	}
+
+	return
+}
+
+func stringValueOrNull(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(s)
+}
@@ -0,0 +1,31 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestReadS3WorkspaceMetadata(t *testing.T) {
+	_, client := newFakeS3Client(t, &fakeS3Objects{objects: map[string][]byte{
+		"/backups/a": []byte(fakeTfeState),
+		"/backups/c": []byte(fakeTfeState),
+	}})
+	ctx := context.Background()
+
+	// b was deleted between listing and reading its metadata.
+	found, _, diags := readS3WorkspaceMetadata(ctx, client, "backups", []string{"a", "b", "c"})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if want := []string{"a", "c"}; !slices.Equal(found, want) {
+		t.Errorf("got keys %q, want %q", found, want)
+	}
+
+	if _, _, diags := readS3WorkspaceMetadata(ctx, client, "denied", []string{"a"}); !diags.HasError() {
+		t.Error("got no error for a denied object")
+	}
+}
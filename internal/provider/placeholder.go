@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	placeholderNone   = "none"
+	placeholderMarker = "marker"
+	placeholderTags   = "tags"
+
+	// placeholderTagKey is the tag set on an existing object by the tags
+	// placeholder.
+	placeholderTagKey = "tfsync-placeholder"
+)
+
+// placeholderMarkerContents is written in place of the state of a workspace
+// without state, so that every workspace has an object.
+type placeholderMarkerContents struct {
+	Placeholder bool   `json:"tfsync_placeholder"`
+	WorkspaceId string `json:"workspace_id"`
+	CreatedAt   string `json:"created_at"`
+	Reason      string `json:"reason"`
+}
+
+func placeholderAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: fmt.Sprintf("what to write when `ignore_empty` ignores a workspace without state. `%s` writes nothing, `%s` writes a small json object with the workspace id, a timestamp and the reason, `%s` tags an existing object with `%s`. The placeholder is replaced by the state once the workspace has state. Defaults to `%s`", placeholderNone, placeholderMarker, placeholderTags, placeholderTagKey, placeholderNone),
+		Optional:            true,
+		Validators: []validator.String{
+			stringvalidator.OneOf(placeholderNone, placeholderMarker, placeholderTags),
+		},
+	}
+}
+
+func placeholderMode(v types.String) string {
+	if v.IsNull() || v.IsUnknown() {
+		return placeholderNone
+	}
+	return v.ValueString()
+}
+
+func newPlaceholderMarker(workspaceId string, now time.Time) ([]byte, error) {
+	return json.MarshalIndent(placeholderMarkerContents{
+		Placeholder: true,
+		WorkspaceId: workspaceId,
+		CreatedAt:   now.UTC().Format(time.RFC3339),
+		Reason:      errWorkspaceNoState.Error(),
+	}, "", "  ")
+}
+
+// parsePlaceholderMarker returns the marker in contents, or false if contents
+// are not one, e.g. state.
+func parsePlaceholderMarker(contents []byte) (marker placeholderMarkerContents, ok bool) {
+	if err := json.Unmarshal(contents, &marker); err != nil {
+		return marker, false
+	}
+
+	return marker, marker.Placeholder
+}
+
+// placeholderTagValue only uses characters allowed in s3 tag values.
+func placeholderTagValue(now time.Time) string {
+	return fmt.Sprintf("%s as of %s", errWorkspaceNoState, now.UTC().Format(time.RFC3339))
+}
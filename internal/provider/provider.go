@@ -10,10 +10,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/hashicorp/go-tfe"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -36,7 +38,12 @@ type TfSyncProvider struct {
 type TfSyncProviderModel struct {
 	Region                    types.String                    `tfsdk:"region"`
 	SoftDelete                types.Bool                      `tfsdk:"soft_delete"`
+	MaxConcurrentDownloads    types.Int64                     `tfsdk:"max_concurrent_downloads"`
+	MaxConcurrentUploads      types.Int64                     `tfsdk:"max_concurrent_uploads"`
+	MaxRetries                types.Int64                     `tfsdk:"max_retries"`
+	RetryMode                 types.String                    `tfsdk:"retry_mode"`
 	AssumeRoleWithWebIdentity *assumeRoleWithWebIdentityBlock `tfsdk:"assume_role_with_web_identity"`
+	StateCache                *stateCacheBlock                `tfsdk:"state_cache"`
 }
 
 type assumeRoleWithWebIdentityBlock struct {
@@ -44,6 +51,11 @@ type assumeRoleWithWebIdentityBlock struct {
 	WebIdentityTokenFile types.String `tfsdk:"web_identity_token_file"`
 }
 
+type stateCacheBlock struct {
+	MaxMemoryMB    types.Int64  `tfsdk:"max_memory_mb"`
+	SpillDirectory types.String `tfsdk:"spill_directory"`
+}
+
 func (p *TfSyncProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "tfsync"
 	resp.Version = p.version
@@ -62,6 +74,26 @@ func (p *TfSyncProvider) Schema(ctx context.Context, req provider.SchemaRequest,
 				Description:         "enable soft delete on s3 object",
 				Optional:            true,
 			},
+			"max_concurrent_downloads": schema.Int64Attribute{
+				MarkdownDescription: "maximum number of requests to tfe at once across all resources, including state downloads. Unlimited when unset",
+				Description:         "maximum number of requests to tfe at once across all resources, including state downloads. Unlimited when unset",
+				Optional:            true,
+			},
+			"max_concurrent_uploads": schema.Int64Attribute{
+				MarkdownDescription: "maximum number of objects uploaded to s3 at once across all resources. Unlimited when unset",
+				Description:         "maximum number of objects uploaded to s3 at once across all resources. Unlimited when unset",
+				Optional:            true,
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("maximum number of times a throttled or failed tfe or s3 request is retried. Defaults to `%d`", defaultMaxRetries),
+				Description:         fmt.Sprintf("maximum number of times a throttled or failed tfe or s3 request is retried. Defaults to %d", defaultMaxRetries),
+				Optional:            true,
+			},
+			"retry_mode": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("aws sdk retry mode, one of `%s` or `%s`. `%s` additionally rate limits s3 requests client side once throttled. Defaults to `%s`", retryModeStandard, retryModeAdaptive, retryModeAdaptive, retryModeStandard),
+				Description:         fmt.Sprintf("aws sdk retry mode, one of %s or %s. %s additionally rate limits s3 requests client side once throttled. Defaults to %s", retryModeStandard, retryModeAdaptive, retryModeAdaptive, retryModeStandard),
+				Optional:            true,
+			},
 		},
 		Blocks: map[string]schema.Block{
 			"assume_role_with_web_identity": schema.SingleNestedBlock{
@@ -80,18 +112,45 @@ func (p *TfSyncProvider) Schema(ctx context.Context, req provider.SchemaRequest,
 					},
 				},
 			},
+			"state_cache": schema.SingleNestedBlock{
+				MarkdownDescription: "configure the cache of downloaded state files shared by all resources",
+				Description:         "configure the cache of downloaded state files shared by all resources",
+				Attributes: map[string]schema.Attribute{
+					"max_memory_mb": schema.Int64Attribute{
+						MarkdownDescription: fmt.Sprintf("maximum size of state files kept in memory, in MiB. Defaults to `%d`", defaultStateCacheMaxMemoryMB),
+						Description:         fmt.Sprintf("maximum size of state files kept in memory, in MiB. Defaults to %d", defaultStateCacheMaxMemoryMB),
+						Optional:            true,
+					},
+					"spill_directory": schema.StringAttribute{
+						MarkdownDescription: "directory to write state files to once the memory limit is reached. State files are written with mode `0600` to a temporary directory that is removed when the provider exits",
+						Description:         "directory to write state files to once the memory limit is reached. State files are written with mode 0600 to a temporary directory that is removed when the provider exits",
+						Optional:            true,
+					},
+				},
+			},
 		},
 	}
 }
 
 type ResourceConfigureData struct {
 	softDelete bool
+	maxRetries int
 	tfeClient  *tfe.Client
-	s3Client   *s3.Client
+	kmsClient  *kms.Client
+	stateCache *stateCache
+	downloads  semaphore
+	uploads    semaphore
+
+	// s3Clients creates the s3 client of each bucket, in the bucket's region
+	// unless a resource configures another region, endpoint or credentials.
+	s3Clients *s3ClientCache
+
+	// workspaces lists the workspaces of each organization once per run.
+	workspaces *workspaceListCache
 }
 
-func NewResourceConfigureData(softDelete bool, tfeClient *tfe.Client, s3Client *s3.Client) *ResourceConfigureData {
-	return &ResourceConfigureData{softDelete: softDelete, tfeClient: tfeClient, s3Client: s3Client}
+func NewResourceConfigureData(softDelete bool, maxRetries int, tfeClient *tfe.Client, kmsClient *kms.Client, stateCache *stateCache, downloads semaphore, uploads semaphore, s3Clients *s3ClientCache, workspaces *workspaceListCache) *ResourceConfigureData {
+	return &ResourceConfigureData{softDelete: softDelete, maxRetries: maxRetries, tfeClient: tfeClient, kmsClient: kmsClient, stateCache: stateCache, downloads: downloads, uploads: uploads, s3Clients: s3Clients, workspaces: workspaces}
 }
 
 func (p *TfSyncProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
@@ -103,13 +162,40 @@ func (p *TfSyncProvider) Configure(ctx context.Context, req provider.ConfigureRe
 		return
 	}
 
-	tfeClient, err := tfe.NewClient(tfe.DefaultConfig())
+	maxRetries := int64(defaultMaxRetries)
+	if !data.MaxRetries.IsNull() {
+		maxRetries = data.MaxRetries.ValueInt64()
+	}
+
+	if maxRetries < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_retries"), "retries", "max_retries must not be negative")
+		return
+	}
+
+	retryMode := aws.RetryModeStandard
+	switch data.RetryMode.ValueString() {
+	case "", retryModeStandard:
+	case retryModeAdaptive:
+		retryMode = aws.RetryModeAdaptive
+	default:
+		resp.Diagnostics.AddAttributeError(path.Root("retry_mode"), "retries", fmt.Sprintf("retry_mode must be one of %q or %q", retryModeStandard, retryModeAdaptive))
+		return
+	}
+
+	downloads := newSemaphore(data.MaxConcurrentDownloads.ValueInt64())
+	uploads := newSemaphore(data.MaxConcurrentUploads.ValueInt64())
+
+	tfeClient, err := newTfeClient("", "", int(maxRetries), downloads)
 	if err != nil {
 		resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to create tfe client: %s", err))
 		return
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(data.Region.ValueString()))
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(data.Region.ValueString()),
+		config.WithRetryMaxAttempts(int(maxRetries)+1),
+		config.WithRetryMode(retryMode),
+	)
 	if err != nil {
 		resp.Diagnostics.AddError("aws client", fmt.Sprintf("failed to load AWS configuration: %s", err))
 		return
@@ -122,8 +208,22 @@ func (p *TfSyncProvider) Configure(ctx context.Context, req provider.ConfigureRe
 	}
 
 	s3Client := s3.NewFromConfig(cfg)
+	kmsClient := kms.NewFromConfig(cfg)
+
+	maxMemoryMB, spillDir := int64(defaultStateCacheMaxMemoryMB), ""
+	if data.StateCache != nil {
+		if !data.StateCache.MaxMemoryMB.IsNull() {
+			maxMemoryMB = data.StateCache.MaxMemoryMB.ValueInt64()
+		}
+		spillDir = data.StateCache.SpillDirectory.ValueString()
+	}
+
+	if maxMemoryMB < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("state_cache").AtName("max_memory_mb"), "state cache", "max_memory_mb must not be negative")
+		return
+	}
 
-	cd := NewResourceConfigureData(data.SoftDelete.ValueBool(), tfeClient, s3Client)
+	cd := NewResourceConfigureData(data.SoftDelete.ValueBool(), int(maxRetries), tfeClient, kmsClient, newStateCache(maxMemoryMB<<20, spillDir), downloads, uploads, newS3ClientCache(cfg, s3Client), newWorkspaceListCache(tfeClient))
 
 	resp.DataSourceData = cd
 	resp.ResourceData = cd
@@ -131,14 +231,59 @@ func (p *TfSyncProvider) Configure(ctx context.Context, req provider.ConfigureRe
 	tflog.Info(ctx, "Configured tfsync client", map[string]any{"aws_region": s3Client.Options().Region})
 }
 
+// newTfeClient creates a tfe client retrying requests at most maxRetries
+// times and sending at most as many requests at once as requests allows.
+func newTfeClient(hostname string, token string, maxRetries int, requests semaphore) (*tfe.Client, error) {
+	tfeConfig := tfe.DefaultConfig()
+	if hostname != "" {
+		tfeConfig.Address = fmt.Sprintf("https://%s", hostname)
+	}
+	if token != "" {
+		tfeConfig.Token = token
+	}
+	configureTfeTransport(tfeConfig, maxRetries, requests)
+
+	return tfe.NewClient(tfeConfig)
+}
+
+// configureTfeTransport bounds the retries and concurrent requests of a tfe
+// client. go-tfe retries 429 responses up to 30 times, and server errors when
+// RetryServerErrors is set. Retries are left to retryTransport instead, which
+// never passes a 429 up, so that max_retries bounds them.
+func configureTfeTransport(tfeConfig *tfe.Config, maxRetries int, requests semaphore) {
+	tfeConfig.RetryServerErrors = false
+	tfeConfig.HTTPClient.Transport = &retryTransport{
+		base:          &semaphoreTransport{base: tfeConfig.HTTPClient.Transport, sem: requests},
+		maxRetries:    maxRetries,
+		failThrottled: true,
+	}
+}
+
 func (p *TfSyncProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewS3ObjectResource,
+		NewOrganizationBackupResource,
+		NewWorkspaceRestoreResource,
+		NewStateMigrationResource,
+		NewLocalFileResource,
+		NewHTTPBackendResource,
+		NewHTTPObjectResource,
+		NewGitRepositoryResource,
+		NewOCIArtifactResource,
+		NewAzureBlobResource,
+		NewGCSObjectResource,
+		NewS3ReplicatedObjectResource,
 	}
 }
 
 func (p *TfSyncProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewStateVersionDataSource,
+		NewS3BackupDataSource,
+		NewBackupStatusDataSource,
+		NewOrphanedBackupsDataSource,
+		NewWorkspacesDataSource,
+	}
 }
 
 func New(version string) func() provider.Provider {
@@ -0,0 +1,182 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultMaxRetries = 3
+
+	retryModeStandard = "standard"
+	retryModeAdaptive = "adaptive"
+
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// semaphore bounds the number of concurrent operations shared by all
+// resources. A nil semaphore does not limit anything.
+type semaphore chan struct{}
+
+func newSemaphore(n int64) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s semaphore) release() {
+	if s == nil {
+		return
+	}
+	<-s
+}
+
+// semaphoreTransport holds a slot of sem from sending a request until its
+// response body is closed.
+type semaphoreTransport struct {
+	base http.RoundTripper
+	sem  semaphore
+}
+
+func (t *semaphoreTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.sem.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.sem.release()
+		return nil, err
+	}
+
+	resp.Body = &semaphoreBody{ReadCloser: resp.Body, release: sync.OnceFunc(t.sem.release)}
+	return resp, nil
+}
+
+type semaphoreBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *semaphoreBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// retryTransport retries throttled and failed requests, honouring the
+// Retry-After header sent with 429 and 503 responses.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+
+	// failThrottled returns an error instead of a 429 response once retries
+	// are exhausted, so that a retrying client above it, like the one of
+	// go-tfe, does not retry the request again.
+	failThrottled bool
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.maxRetries || !retryable(req, resp, err) {
+			if t.failThrottled && err == nil && resp.StatusCode == http.StatusTooManyRequests {
+				resp.Body.Close()
+				return nil, fmt.Errorf("request throttled, gave up after %d retries", attempt)
+			}
+			return resp, err
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, berr := req.GetBody()
+			if berr != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+
+		delay := retryDelay(attempt, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		tflog.Debug(req.Context(), "tfsync retrying tfe request", map[string]any{"attempt": attempt + 1, "delay": delay.String(), "url": req.URL.Redacted()})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	if err != nil {
+		return idempotent
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+
+	return false
+}
+
+// retryDelay returns the delay requested by the server via Retry-After,
+// falling back to exponential backoff.
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if v := resp.Header.Get("Retry-After"); v != "" {
+			if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+				return min(time.Duration(seconds)*time.Second, retryMaxDelay)
+			}
+			if t, err := http.ParseTime(v); err == nil {
+				return min(max(time.Until(t), 0), retryMaxDelay)
+			}
+		}
+	}
+
+	if delay := retryBaseDelay << attempt; attempt < 16 && delay < retryMaxDelay {
+		return delay
+	}
+
+	return retryMaxDelay
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// newThrottlingTfeServer answers pings and throttles all other requests,
+// counting the requests it throttled.
+func newThrottlingTfeServer(t *testing.T, throttled *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.Header().Set("TFP-API-Version", "2.5")
+		if r.URL.Path == "/api/v2/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		throttled.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestTfeClientMaxRetries(t *testing.T) {
+	for _, maxRetries := range []int{0, 1, 3} {
+		var throttled atomic.Int32
+		srv := newThrottlingTfeServer(t, &throttled)
+
+		cfg := tfe.DefaultConfig()
+		cfg.Address = srv.URL
+		cfg.Token = "token"
+		configureTfeTransport(cfg, maxRetries, nil)
+
+		client, err := tfe.NewClient(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Workspaces.ReadByID(context.Background(), "ws-abc"); err == nil {
+			t.Errorf("max_retries %d: got no error for a throttled request", maxRetries)
+		}
+		if got, want := int(throttled.Load()), maxRetries+1; got != want {
+			t.Errorf("max_retries %d: got %d attempts, want %d", maxRetries, got, want)
+		}
+	}
+}
+
+func TestSemaphoreTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("state"))
+	}))
+	t.Cleanup(srv.Close)
+
+	sem := newSemaphore(1)
+	client := &http.Client{Transport: &semaphoreTransport{base: http.DefaultTransport, sem: sem}}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The slot is held until the body is closed.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sem.acquire(ctx); err == nil {
+		t.Fatal("acquired a slot held by an open response body")
+	}
+
+	resp.Body.Close()
+	resp.Body.Close()
+
+	if err := sem.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sem.release()
+}
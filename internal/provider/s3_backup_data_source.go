@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &S3BackupDataSource{}
+
+func NewS3BackupDataSource() datasource.DataSource {
+	return &S3BackupDataSource{}
+}
+
+type S3BackupDataSource struct {
+	s3Clients *s3ClientCache
+}
+
+type S3BackupDataSourceModel struct {
+	Id                   types.String `tfsdk:"id"`
+	Bucket               types.String `tfsdk:"bucket"`
+	Key                  types.String `tfsdk:"key"`
+	VersionId            types.String `tfsdk:"version_id"`
+	Serial               types.Int64  `tfsdk:"serial"`
+	Lineage              types.String `tfsdk:"lineage"`
+	TerraformVersion     types.String `tfsdk:"terraform_version"`
+	Outputs              types.Map    `tfsdk:"outputs"`
+	SensitiveOutputs     types.Map    `tfsdk:"sensitive_outputs"`
+	ResourceAddresses    types.List   `tfsdk:"resource_addresses"`
+	BucketContentsSha256 types.String `tfsdk:"bucket_contents_sha256"`
+}
+
+func (d *S3BackupDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_s3_backup"
+}
+
+func (d *S3BackupDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Data source to read a tf-state backup written by `tfsync_s3_object`. The object contents are verified against the sha256 checksum recorded by s3 on upload",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "bucket and key of the backup",
+				Computed:            true,
+			},
+			"bucket": schema.StringAttribute{
+				MarkdownDescription: "s3 bucket",
+				Required:            true,
+				Validators:          s3BucketValidators(),
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "s3 bucket key",
+				Required:            true,
+				Validators:          s3KeyValidators(),
+			},
+			"version_id": schema.StringAttribute{
+				MarkdownDescription: "s3 object version to read. Defaults to the latest version",
+				Optional:            true,
+			},
+			"serial": schema.Int64Attribute{
+				MarkdownDescription: "state serial",
+				Computed:            true,
+			},
+			"lineage": schema.StringAttribute{
+				MarkdownDescription: "state lineage",
+				Computed:            true,
+			},
+			"terraform_version": schema.StringAttribute{
+				MarkdownDescription: "terraform version that wrote the state",
+				Computed:            true,
+			},
+			"outputs": schema.MapAttribute{
+				MarkdownDescription: "non-sensitive root module outputs, json encoded. Use `jsondecode` to read the value",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"sensitive_outputs": schema.MapAttribute{
+				MarkdownDescription: "sensitive root module outputs, json encoded. Use `jsondecode` to read the value",
+				Computed:            true,
+				Sensitive:           true,
+				ElementType:         types.StringType,
+			},
+			"resource_addresses": schema.ListAttribute{
+				MarkdownDescription: "address of every resource instance in the state",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"bucket_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of s3 bucket object contents",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *S3BackupDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.s3Clients = data.s3Clients
+}
+
+func (d *S3BackupDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.s3Clients == nil {
+		resp.Diagnostics.AddError("provider", "nil s3 client cache")
+		return
+	}
+
+	var data S3BackupDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := d.s3Clients.forBucket(ctx, data.Bucket.ValueString(), s3ClientOptions{})
+	if err != nil {
+		resp.Diagnostics.AddError("s3 client", err.Error())
+		return
+	}
+
+	contents, diags := getVerifiedS3ObjectContents(ctx, client, data.Bucket.ValueString(), data.Key.ValueString(), data.VersionId.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := parseStateFile(contents)
+	if err != nil {
+		resp.Diagnostics.AddError("state", fmt.Sprintf("s3://%s/%s: %s", data.Bucket.ValueString(), data.Key.ValueString(), err))
+		return
+	}
+
+	outputs := make(map[string]string)
+	sensitiveOutputs := make(map[string]string)
+	for name, o := range state.Outputs {
+		if o.Sensitive {
+			sensitiveOutputs[name] = string(o.Value)
+		} else {
+			outputs[name] = string(o.Value)
+		}
+	}
+
+	data.Id = types.StringValue(fmt.Sprintf("%s/%s", data.Bucket.ValueString(), data.Key.ValueString()))
+	data.Serial = types.Int64Value(state.Serial)
+	data.Lineage = types.StringValue(state.Lineage)
+	data.TerraformVersion = types.StringValue(state.TerraformVersion)
+	data.BucketContentsSha256 = sha256Contents(contents)
+
+	data.Outputs, diags = types.MapValueFrom(ctx, types.StringType, outputs)
+	resp.Diagnostics.Append(diags...)
+
+	data.SensitiveOutputs, diags = types.MapValueFrom(ctx, types.StringType, sensitiveOutputs)
+	resp.Diagnostics.Append(diags...)
+
+	data.ResourceAddresses, diags = types.ListValueFrom(ctx, types.StringType, state.resourceAddresses())
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,202 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// s3DiscoveryRegion is used to look up the region of a bucket when neither
+// the resource nor the provider configure one. s3 redirects requests for
+// buckets in other regions, naming the bucket's region in the response.
+const s3DiscoveryRegion = "us-east-1"
+
+// s3ClientOptions overrides parts of the provider's aws configuration for a
+// single s3 client. The zero value uses the provider's configuration as is.
+type s3ClientOptions struct {
+	Region string
+
+	// Endpoint replaces the s3 endpoint, e.g. for s3 compatible stores.
+	// Requests use path style addressing.
+	Endpoint string
+
+	// AccessKey and SecretKey replace the provider's credentials. RoleArn is
+	// assumed using the provider's credentials, or AccessKey and SecretKey
+	// when set.
+	AccessKey       string
+	SecretKey       string
+	RoleArn         string
+	RoleExternalId  string
+	RoleSessionName string
+}
+
+// s3ClientCache shares s3 clients between resources, so that the credentials
+// of an assumed role are cached and refreshed once per role and region rather
+// than per resource.
+type s3ClientCache struct {
+	base aws.Config
+
+	mu      sync.Mutex
+	clients map[s3ClientOptions]*s3.Client
+
+	// regions maps the bucket and credentials a region was discovered with
+	// to the result of the discovery.
+	regions map[s3BucketRegionKey]s3BucketRegion
+}
+
+type s3BucketRegionKey struct {
+	Bucket  string
+	Options s3ClientOptions
+}
+
+type s3BucketRegion struct {
+	region string
+	err    error
+}
+
+// newS3ClientCache creates a cache of clients derived from base. client is
+// the provider's client, created from base without any options.
+func newS3ClientCache(base aws.Config, client *s3.Client) *s3ClientCache {
+	return &s3ClientCache{
+		base:    base,
+		clients: map[s3ClientOptions]*s3.Client{{}: client},
+		regions: make(map[s3BucketRegionKey]s3BucketRegion),
+	}
+}
+
+// get returns the client for opts, creating it on first use.
+func (c *s3ClientCache) get(opts s3ClientOptions) *s3.Client {
+	if opts.Region == c.base.Region {
+		opts.Region = ""
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.clients[opts]; ok {
+		return client
+	}
+
+	client := newS3Client(c.base, opts)
+	c.clients[opts] = client
+
+	return client
+}
+
+// forBucket returns the client for opts in the region of bucket. Without a
+// region or endpoint in opts the bucket's region is discovered once per run.
+// A failed discovery is an error rather than a guess at the provider's
+// region, where requests would fail with less helpful errors.
+func (c *s3ClientCache) forBucket(ctx context.Context, bucket string, opts s3ClientOptions) (*s3.Client, error) {
+	if opts.Region != "" || opts.Endpoint != "" {
+		return c.get(opts), nil
+	}
+
+	key := s3BucketRegionKey{Bucket: bucket, Options: opts}
+
+	c.mu.Lock()
+	found, ok := c.regions[key]
+	c.mu.Unlock()
+
+	if !ok {
+		discoveryOpts := opts
+		discoveryOpts.Region = c.base.Region
+		if discoveryOpts.Region == "" {
+			discoveryOpts.Region = s3DiscoveryRegion
+		}
+
+		found.region, found.err = discoverS3BucketRegion(ctx, c.get(discoveryOpts), bucket)
+		if found.err != nil && ctx.Err() != nil {
+			// A cancelled run says nothing about the bucket.
+			return nil, found.err
+		}
+
+		c.mu.Lock()
+		c.regions[key] = found
+		c.mu.Unlock()
+	}
+
+	if found.err != nil {
+		return nil, fmt.Errorf("failed to discover the region of bucket %s, set a region to skip discovery: %w", bucket, found.err)
+	}
+
+	opts.Region = found.region
+	return c.get(opts), nil
+}
+
+// discoverS3BucketRegion looks up the region of bucket with HeadBucket, which
+// names the region even when the bucket is in another region than client or
+// access is denied, falling back to GetBucketLocation.
+func discoverS3BucketRegion(ctx context.Context, client *s3.Client, bucket string) (string, error) {
+	out, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	if err == nil && aws.ToString(out.BucketRegion) != "" {
+		return aws.ToString(out.BucketRegion), nil
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		if region := respErr.Response.Header.Get("X-Amz-Bucket-Region"); region != "" {
+			return region, nil
+		}
+	}
+
+	loc, lerr := client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{Bucket: aws.String(bucket)})
+	if lerr != nil {
+		return "", errors.Join(err, lerr)
+	}
+
+	// Buckets in us-east-1 have no location constraint, and EU is the legacy
+	// name of eu-west-1.
+	switch loc.LocationConstraint {
+	case "":
+		return "us-east-1", nil
+	case "EU":
+		return "eu-west-1", nil
+	default:
+		return string(loc.LocationConstraint), nil
+	}
+}
+
+// newS3Client creates an s3 client from the provider's aws configuration
+// with opts applied.
+func newS3Client(base aws.Config, opts s3ClientOptions) *s3.Client {
+	cfg := base.Copy()
+
+	if opts.Region != "" {
+		cfg.Region = opts.Region
+	}
+
+	if opts.AccessKey != "" {
+		cfg.Credentials = credentials.NewStaticCredentialsProvider(opts.AccessKey, opts.SecretKey, "")
+	}
+
+	if opts.RoleArn != "" {
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), opts.RoleArn, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "tfsync"
+			if opts.RoleSessionName != "" {
+				o.RoleSessionName = opts.RoleSessionName
+			}
+			if opts.RoleExternalId != "" {
+				o.ExternalID = aws.String(opts.RoleExternalId)
+			}
+		}))
+	}
+
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+}
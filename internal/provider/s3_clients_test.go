@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3Regions answers every request with 403, naming the region of the
+// bucket "located" like s3 does, and counts the requests per bucket.
+type fakeS3Regions struct {
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func (f *fakeS3Regions) RoundTrip(r *http.Request) (*http.Response, error) {
+	bucket, _, _ := strings.Cut(r.URL.Host, ".")
+
+	f.mu.Lock()
+	f.requests[bucket]++
+	f.mu.Unlock()
+
+	header := make(http.Header)
+	if bucket == "located" {
+		header.Set("X-Amz-Bucket-Region", "eu-west-2")
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusForbidden,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    r,
+	}, nil
+}
+
+func TestS3ClientCacheForBucket(t *testing.T) {
+	f := &fakeS3Regions{requests: make(map[string]int)}
+	base := aws.Config{
+		Region:           "eu-central-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("test", "test", ""),
+		HTTPClient:       &http.Client{Transport: f},
+		RetryMaxAttempts: 1,
+	}
+	c := newS3ClientCache(base, s3.NewFromConfig(base))
+	ctx := context.Background()
+
+	for range 2 {
+		client, err := c.forBucket(ctx, "located", s3ClientOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if region := client.Options().Region; region != "eu-west-2" {
+			t.Errorf("got region %s, want eu-west-2", region)
+		}
+
+		// Failures are not replaced by the provider's region.
+		if client, err := c.forBucket(ctx, "denied", s3ClientOptions{}); err == nil {
+			t.Errorf("got a client in %s, want an error", client.Options().Region)
+		}
+
+		if _, err := c.forBucket(ctx, "configured", s3ClientOptions{Region: "us-west-2"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Results, including failures, are discovered once.
+	for bucket, want := range map[string]int{"located": 1, "denied": 2, "configured": 0} {
+		if got := f.requests[bucket]; got != want {
+			t.Errorf("%s: got %d requests, want %d", bucket, got, want)
+		}
+	}
+}
@@ -4,32 +4,37 @@
 package provider
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &S3ObjectResource{}
 var _ resource.ResourceWithImportState = &S3ObjectResource{}
+var _ resource.ResourceWithModifyPlan = &S3ObjectResource{}
+
+const (
+	defaultCreateTimeout = 20 * time.Minute
+	defaultReadTimeout   = 10 * time.Minute
+	defaultUpdateTimeout = 20 * time.Minute
+	defaultDeleteTimeout = 5 * time.Minute
+)
 
 func NewS3ObjectResource() resource.Resource {
 	return &S3ObjectResource{}
@@ -38,21 +43,41 @@ func NewS3ObjectResource() resource.Resource {
 type S3ObjectResource struct {
 	softDelete bool
 	tfeClient  *tfe.Client
-	s3Client   *s3.Client
+	s3Clients  *s3ClientCache
+	stateCache *stateCache
+	workspaces *workspaceListCache
+	uploads    semaphore
 }
 
 type S3ObjectResourceModel struct {
-	Id                   types.String `tfsdk:"id"`
-	WorkspaceId          types.String `tfsdk:"workspace_id"`
-	Bucket               types.String `tfsdk:"bucket"`
-	Key                  types.String `tfsdk:"key"`
-	StateContentsSha256  types.String `tfsdk:"state_contents_sha256"`
-	BucketContentsSha256 types.String `tfsdk:"bucket_contents_sha256"`
-	KmsKeyId             types.String `tfsdk:"kms_key_id"`
-	IgnoreEmpty          types.Bool   `tfsdk:"ignore_empty"`
-	Ignored              types.Bool   `tfsdk:"ignored"`
-	SoftDelete           types.Bool   `tfsdk:"soft_delete"`
-	Tags                 types.Map    `tfsdk:"tags"`
+	Id                   types.String     `tfsdk:"id"`
+	WorkspaceId          types.String     `tfsdk:"workspace_id"`
+	Organization         types.String     `tfsdk:"organization"`
+	WorkspaceName        types.String     `tfsdk:"workspace_name"`
+	Bucket               types.String     `tfsdk:"bucket"`
+	Key                  types.String     `tfsdk:"key"`
+	StateContentsSha256  types.String     `tfsdk:"state_contents_sha256"`
+	BucketContentsSha256 types.String     `tfsdk:"bucket_contents_sha256"`
+	KmsKeyId             types.String     `tfsdk:"kms_key_id"`
+	Region               types.String     `tfsdk:"region"`
+	Endpoint             types.String     `tfsdk:"endpoint"`
+	AssumeRole           *assumeRoleBlock `tfsdk:"assume_role"`
+	IgnoreEmpty          types.Bool       `tfsdk:"ignore_empty"`
+	OnWorkspaceMissing   types.String     `tfsdk:"on_workspace_missing"`
+	OnAccessDenied       types.String     `tfsdk:"on_access_denied"`
+	Ignored              types.Bool       `tfsdk:"ignored"`
+	WorkspaceMissing     types.Bool       `tfsdk:"workspace_missing"`
+	Placeholder          types.String     `tfsdk:"placeholder"`
+	PlaceholderWritten   types.Bool       `tfsdk:"placeholder_written"`
+	SoftDelete           types.Bool       `tfsdk:"soft_delete"`
+	Tags                 types.Map        `tfsdk:"tags"`
+	Timeouts             timeouts.Value   `tfsdk:"timeouts"`
+}
+
+type assumeRoleBlock struct {
+	RoleARN     types.String `tfsdk:"role_arn"`
+	ExternalId  types.String `tfsdk:"external_id"`
+	SessionName types.String `tfsdk:"session_name"`
 }
 
 func (r *S3ObjectResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -71,16 +96,33 @@ func (r *S3ObjectResource) Schema(ctx context.Context, req resource.SchemaReques
 				},
 			},
 			"workspace_id": schema.StringAttribute{
-				MarkdownDescription: "terraform workspace id",
-				Required:            true,
+				MarkdownDescription: "terraform workspace id. Conflicts with `workspace_name`, resolved from it when unset",
+				Optional:            true,
+				Computed:            true,
+				Validators: append(workspaceIdValidators(),
+					stringvalidator.ExactlyOneOf(path.MatchRoot("workspace_name")),
+				),
+			},
+			"organization": schema.StringAttribute{
+				MarkdownDescription: "terraform organization of `workspace_name`",
+				Optional:            true,
+			},
+			"workspace_name": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace name. Requires `organization`. Once resolved the workspace id is kept, so renaming the workspace does not change which workspace is synced",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("organization")),
+				},
 			},
 			"bucket": schema.StringAttribute{
 				MarkdownDescription: "s3 bucket",
 				Required:            true,
+				Validators:          s3BucketValidators(),
 			},
 			"key": schema.StringAttribute{
 				MarkdownDescription: "s3 bucket key",
 				Required:            true,
+				Validators:          s3KeyValidators(),
 			},
 			"state_contents_sha256": schema.StringAttribute{
 				MarkdownDescription: "sha256 sum of tf state",
@@ -93,15 +135,37 @@ func (r *S3ObjectResource) Schema(ctx context.Context, req resource.SchemaReques
 			"kms_key_id": schema.StringAttribute{
 				MarkdownDescription: "kms key id",
 				Optional:            true,
+				Validators:          kmsKeyIdValidators(),
+			},
+			"region": schema.StringAttribute{
+				MarkdownDescription: "aws region of the bucket. Discovered from the bucket when unset, failing if that is not possible",
+				Optional:            true,
+				Validators:          awsRegionValidators(),
+			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "s3 endpoint url, e.g. for an s3 compatible store. Requests use path style addressing",
+				Optional:            true,
+				Validators:          httpAddressValidators(),
 			},
 			"ignore_empty": schema.BoolAttribute{
-				MarkdownDescription: "ignore if no state is found",
+				MarkdownDescription: "ignore if no state is found. Deleted and inaccessible workspaces are handled by `on_workspace_missing` and `on_access_denied` when set",
 				Optional:            true,
 			},
+			"on_workspace_missing": onWorkspaceMissingAttribute(),
+			"on_access_denied":     onAccessDeniedAttribute(),
 			"ignored": schema.BoolAttribute{
 				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
 				Computed:            true,
 			},
+			"workspace_missing": schema.BoolAttribute{
+				MarkdownDescription: "true if the last refresh found the workspace deleted. With `on_workspace_missing` set to `delete_backup` the next apply deletes the backup",
+				Computed:            true,
+			},
+			"placeholder": placeholderAttribute(),
+			"placeholder_written": schema.BoolAttribute{
+				MarkdownDescription: "true if the object is a placeholder written by `placeholder` rather than state",
+				Computed:            true,
+			},
 			"soft_delete": schema.BoolAttribute{
 				MarkdownDescription: "use soft delete",
 				Optional:            true,
@@ -110,7 +174,34 @@ func (r *S3ObjectResource) Schema(ctx context.Context, req resource.SchemaReques
 				MarkdownDescription: "A map of default tags to apply to all resources.",
 				Optional:            true,
 				ElementType:         types.StringType,
+				Validators:          s3TagsValidators(),
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"assume_role": schema.SingleNestedBlock{
+				MarkdownDescription: "role to assume with the provider's credentials, e.g. one in the account owning the bucket",
+				Attributes: map[string]schema.Attribute{
+					"role_arn": schema.StringAttribute{
+						MarkdownDescription: "role arn to assume",
+						Required:            true,
+						Validators:          iamRoleArnValidators(),
+					},
+					"external_id": schema.StringAttribute{
+						MarkdownDescription: "external id required by the role's trust policy",
+						Optional:            true,
+					},
+					"session_name": schema.StringAttribute{
+						MarkdownDescription: "role session name. Defaults to `tfsync`",
+						Optional:            true,
+					},
+				},
 			},
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
 		},
 	}
 }
@@ -132,7 +223,50 @@ func (r *S3ObjectResource) Configure(ctx context.Context, req resource.Configure
 
 	r.softDelete = data.softDelete
 	r.tfeClient = data.tfeClient
-	r.s3Client = data.s3Client
+	r.s3Clients = data.s3Clients
+	r.stateCache = data.stateCache
+	r.workspaces = data.workspaces
+	r.uploads = data.uploads
+}
+
+// ModifyPlan keeps the workspace id resolved from `workspace_name` as long as
+// the configured organization and workspace name are unchanged, so that the
+// workspace is not resolved again, e.g. to another workspace after a rename.
+// It also schedules an update when a placeholder can be replaced by state, or
+// when the backup of a deleted workspace is to be deleted.
+func (r *S3ObjectResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var state, plan S3ObjectResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.WorkspaceId.IsUnknown() && !plan.WorkspaceName.IsNull() && plan.Organization.Equal(state.Organization) && plan.WorkspaceName.Equal(state.WorkspaceName) {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("workspace_id"), state.WorkspaceId)...)
+	}
+
+	// A refresh found state for a workspace that only had a placeholder,
+	// schedule an update to replace the placeholder with the state.
+	if state.PlaceholderWritten.ValueBool() && !state.Ignored.ValueBool() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("ignored"), types.BoolUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("placeholder_written"), types.BoolUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("state_contents_sha256"), types.StringUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("bucket_contents_sha256"), types.StringUnknown())...)
+	}
+
+	// A refresh found the workspace deleted, schedule an update to delete its
+	// backup. Refreshes only record the marker so that nothing is deleted
+	// outside of an apply.
+	if state.WorkspaceMissing.ValueBool() && (!state.BucketContentsSha256.IsNull() || state.PlaceholderWritten.ValueBool()) &&
+		plan.OnWorkspaceMissing.ValueString() == workspacePolicyDeleteBackup && !r.softDelete && !plan.SoftDelete.ValueBool() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("placeholder_written"), types.BoolUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("bucket_contents_sha256"), types.StringUnknown())...)
+	}
 }
 
 func (r *S3ObjectResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -147,7 +281,27 @@ func (r *S3ObjectResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	state, d, ignored := getStateFile(ctx, r.tfeClient, data.WorkspaceId.ValueString(), data.IgnoreEmpty.ValueBool())
+	timeout, d := data.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp.Diagnostics.Append(r.resolveWorkspaceId(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var tags map[string]string
+	resp.Diagnostics.Append(data.Tags.ElementsAs(ctx, &tags, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, d, ignored := getStateFile(ctx, r.tfeClient, r.stateCache, data.WorkspaceId.ValueString(), data.IgnoreEmpty.ValueBool())
 	resp.Diagnostics.Append(d...)
 	if resp.Diagnostics.HasError() {
 		return
@@ -155,26 +309,33 @@ func (r *S3ObjectResource) Create(ctx context.Context, req resource.CreateReques
 
 	data.Id = newS3ObjectResourceID(&data)
 	data.Ignored = types.BoolValue(ignored)
+	data.WorkspaceMissing = types.BoolValue(false)
 
 	if ignored {
 		data.StateContentsSha256 = types.StringNull()
 		data.BucketContentsSha256 = types.StringNull()
 
+		resp.Diagnostics.Append(r.writePlaceholder(ctx, &data, tags)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
 		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 		return
 	}
 
+	data.PlaceholderWritten = types.BoolValue(false)
 	data.StateContentsSha256 = sha256Contents(state)
 	data.BucketContentsSha256 = sha256Contents(state)
 
-	o := &putObjectOptions{
-		Bucket:   data.Bucket.ValueString(),
-		Key:      data.Key.ValueString(),
-		KmsKeyId: data.KmsKeyId.ValueString(),
-		Contents: state,
+	dst, d := r.destination(ctx, &data)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	resp.Diagnostics.Append(putS3ObjectContents(ctx, r.s3Client, o)...)
+	_, d = writeState(ctx, dst, data.Key.ValueString(), stateEncoding{}, state, destinationMetadata{WorkspaceId: data.WorkspaceId.ValueString(), Tags: tags})
+	resp.Diagnostics.Append(d...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -194,31 +355,109 @@ func (r *S3ObjectResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
-	state, d, ignored := getStateFile(ctx, r.tfeClient, data.WorkspaceId.ValueString(), data.IgnoreEmpty.ValueBool())
+	timeout, d := data.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	state, d, ignored, unavailable := getWorkspaceState(ctx, r.tfeClient, r.stateCache, r.workspaces, data.WorkspaceId.ValueString(), data.Organization.ValueString(), data.IgnoreEmpty.ValueBool())
 	resp.Diagnostics.Append(d...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if unavailable != nil && ignoreUnavailable(unavailable, data.IgnoreEmpty.ValueBool(), data.OnWorkspaceMissing, data.OnAccessDenied) {
+		ignored, unavailable = true, nil
+	}
+
+	if unavailable != nil {
+		object := fmt.Sprintf("s3://%s/%s", data.Bucket.ValueString(), data.Key.ValueString())
+
+		switch workspacePolicy(unavailable, data.OnWorkspaceMissing, data.OnAccessDenied) {
+		case workspacePolicyKeepBackup:
+			resp.Diagnostics.AddWarning("workspace unavailable", fmt.Sprintf("%s: %s, keeping the backup at %s", data.WorkspaceId.ValueString(), unavailable, object))
+			data.WorkspaceMissing = types.BoolValue(errors.Is(unavailable, errWorkspaceMissing))
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		case workspacePolicyRemoveFromState:
+			resp.Diagnostics.AddWarning("workspace unavailable", fmt.Sprintf("%s: %s, removing the resource from state and keeping the backup at %s", data.WorkspaceId.ValueString(), unavailable, object))
+			resp.State.RemoveResource(ctx)
+		case workspacePolicyDeleteBackup:
+			if r.softDelete || data.SoftDelete.ValueBool() {
+				resp.Diagnostics.AddWarning("using soft delete", fmt.Sprintf("%s: %s, removing the resource from state and keeping the backup at %s", data.WorkspaceId.ValueString(), unavailable, object))
+				resp.State.RemoveResource(ctx)
+				return
+			}
+
+			// The backup is deleted by the update ModifyPlan schedules from
+			// the marker, after which there is nothing left to warn about.
+			if !data.BucketContentsSha256.IsNull() || data.PlaceholderWritten.ValueBool() {
+				resp.Diagnostics.AddWarning("workspace unavailable", fmt.Sprintf("%s: %s, the next apply deletes the backup at %s", data.WorkspaceId.ValueString(), unavailable, object))
+			}
+			data.WorkspaceMissing = types.BoolValue(true)
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		default:
+			resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", unavailable))
+		}
+		return
+	}
+
 	data.Id = newS3ObjectResourceID(&data)
 	data.Ignored = types.BoolValue(ignored)
+	data.WorkspaceMissing = types.BoolValue(false)
 
 	if ignored {
 		data.StateContentsSha256 = types.StringNull()
 		data.BucketContentsSha256 = types.StringNull()
 
+		// A deleted marker is written again by recreating the resource.
+		if data.PlaceholderWritten.ValueBool() && placeholderMode(data.Placeholder) == placeholderMarker {
+			dst, d := r.destination(ctx, &data)
+			resp.Diagnostics.Append(d...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+
+			contents, d := dst.get(ctx, data.Key.ValueString())
+			resp.Diagnostics.Append(d...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+
+			if contents == nil {
+				resp.State.RemoveResource(ctx)
+				return
+			}
+
+			data.BucketContentsSha256 = sha256Contents(contents)
+		}
+
 		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 		return
 	}
 
 	data.StateContentsSha256 = sha256Contents(state)
 
-	contents, d := getS3ObjectContents(ctx, r.s3Client, data.Bucket.ValueString(), data.Key.ValueString())
+	dst, d := r.destination(ctx, &data)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	contents, d := getState(ctx, dst, data.Key.ValueString(), stateEncoding{})
 	resp.Diagnostics.Append(d...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if contents == nil {
+		resp.Diagnostics.AddError("s3 client", fmt.Sprintf("failed to get object: s3://%s/%s does not exist", data.Bucket.ValueString(), data.Key.ValueString()))
+		return
+	}
+
 	data.BucketContentsSha256 = sha256Contents(contents)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -230,8 +469,23 @@ func (r *S3ObjectResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	var plan S3ObjectResourceModel
+	var plan, prior S3ObjectResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := plan.Timeouts.Update(ctx, defaultUpdateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp.Diagnostics.Append(r.resolveWorkspaceId(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -242,34 +496,89 @@ func (r *S3ObjectResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	contents, d, ignored := getStateFile(ctx, r.tfeClient, plan.WorkspaceId.ValueString(), plan.IgnoreEmpty.ValueBool())
+	contents, d, ignored, unavailable := getWorkspaceState(ctx, r.tfeClient, r.stateCache, r.workspaces, plan.WorkspaceId.ValueString(), plan.Organization.ValueString(), plan.IgnoreEmpty.ValueBool())
 	resp.Diagnostics.Append(d...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if unavailable != nil && ignoreUnavailable(unavailable, plan.IgnoreEmpty.ValueBool(), plan.OnWorkspaceMissing, plan.OnAccessDenied) {
+		ignored, unavailable = true, nil
+	}
+
+	// remove_from_state removes the resource on refresh, so only keep_backup
+	// and delete_backup can reach an update.
+	if unavailable != nil {
+		policy := workspacePolicy(unavailable, plan.OnWorkspaceMissing, plan.OnAccessDenied)
+		if policy != workspacePolicyKeepBackup && policy != workspacePolicyDeleteBackup {
+			resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", unavailable))
+			return
+		}
+
+		object := fmt.Sprintf("s3://%s/%s", prior.Bucket.ValueString(), prior.Key.ValueString())
+
+		plan.Ignored = prior.Ignored
+		plan.WorkspaceMissing = types.BoolValue(errors.Is(unavailable, errWorkspaceMissing))
+		plan.StateContentsSha256 = prior.StateContentsSha256
+
+		if policy == workspacePolicyKeepBackup || r.softDelete || plan.SoftDelete.ValueBool() {
+			resp.Diagnostics.AddWarning("workspace unavailable", fmt.Sprintf("%s: %s, keeping the backup at %s", plan.WorkspaceId.ValueString(), unavailable, object))
+
+			plan.PlaceholderWritten = prior.PlaceholderWritten
+			plan.BucketContentsSha256 = prior.BucketContentsSha256
+
+			resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+			return
+		}
+
+		dst, d := r.destination(ctx, &prior)
+		resp.Diagnostics.Append(d...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		resp.Diagnostics.Append(dst.delete(ctx, prior.Key.ValueString())...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		resp.Diagnostics.AddWarning("workspace unavailable", fmt.Sprintf("%s: %s, deleted the backup at %s", plan.WorkspaceId.ValueString(), unavailable, object))
+
+		plan.PlaceholderWritten = types.BoolValue(false)
+		plan.BucketContentsSha256 = types.StringNull()
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+		return
+	}
+
 	plan.Ignored = types.BoolValue(ignored)
+	plan.WorkspaceMissing = types.BoolValue(false)
 
 	if ignored {
 		plan.StateContentsSha256 = types.StringNull()
 		plan.BucketContentsSha256 = types.StringNull()
 
+		resp.Diagnostics.Append(r.writePlaceholder(ctx, &plan, tags)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
 		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 		return
 	}
 
+	plan.PlaceholderWritten = types.BoolValue(false)
 	plan.StateContentsSha256 = sha256Contents(contents)
 	plan.BucketContentsSha256 = sha256Contents(contents)
 
-	o := &putObjectOptions{
-		Bucket:   plan.Bucket.ValueString(),
-		Key:      plan.Key.ValueString(),
-		KmsKeyId: plan.KmsKeyId.ValueString(),
-		Contents: contents,
-		Tags:     tags,
+	dst, d := r.destination(ctx, &plan)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	resp.Diagnostics.Append(putS3ObjectContents(ctx, r.s3Client, o)...)
+	_, d = writeState(ctx, dst, plan.Key.ValueString(), stateEncoding{}, contents, destinationMetadata{WorkspaceId: plan.WorkspaceId.ValueString(), Tags: tags})
+	resp.Diagnostics.Append(d...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -289,12 +598,27 @@ func (r *S3ObjectResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
+	timeout, d := data.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	if r.softDelete || data.SoftDelete.ValueBool() {
 		resp.Diagnostics.AddWarning("using soft delete", fmt.Sprintf("bucket: %s, key: %s", data.Bucket.ValueString(), data.Key.ValueString()))
 		return
 	}
 
-	resp.Diagnostics.Append(deleteS3Object(ctx, r.s3Client, data.Bucket.ValueString(), data.Key.ValueString())...)
+	dst, d := r.destination(ctx, &data)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(dst.delete(ctx, data.Key.ValueString())...)
 }
 
 func (r *S3ObjectResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
@@ -306,128 +630,168 @@ func (r *S3ObjectResource) ImportState(ctx context.Context, req resource.ImportS
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
 
-func sha256Contents(contents []byte) basetypes.StringValue {
-	hash := sha256.Sum256(contents)
-	hashString := hex.EncodeToString(hash[:])
-	return types.StringValue(hashString)
-}
-
-func newS3ObjectResourceID(data *S3ObjectResourceModel) basetypes.StringValue {
-	return types.StringValue(fmt.Sprintf("%s/%s/%s", data.WorkspaceId.ValueString(), data.Bucket.ValueString(), data.Key.ValueString()))
-}
-
-func getStateFile(ctx context.Context, client *tfe.Client, workspaceId string, ignoreEmpty bool) (state []byte, diag diag.Diagnostics, ignored bool) {
-	ver, err := client.StateVersions.ReadCurrent(ctx, workspaceId)
-	if err != nil {
-		if ignoreEmpty && errors.Is(err, tfe.ErrResourceNotFound) {
-			ignored = true
-			return
-		}
-
-		diag.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", err))
+// resolveWorkspaceId sets the workspace id of data from its organization and
+// workspace name, unless the plan already has one.
+func (r *S3ObjectResource) resolveWorkspaceId(ctx context.Context, data *S3ObjectResourceModel) (diag diag.Diagnostics) {
+	if !data.WorkspaceId.IsUnknown() && !data.WorkspaceId.IsNull() {
 		return
 	}
 
-	state, err = client.StateVersions.Download(ctx, ver.DownloadURL)
+	ws, err := readWorkspace(ctx, r.tfeClient, "", data.Organization.ValueString(), data.WorkspaceName.ValueString())
 	if err != nil {
-		diag.AddError("tfe client", fmt.Sprintf("failed to download state: %s", err))
+		diag.AddError("tfe client", fmt.Sprintf("failed to read workspace %s/%s: %s", data.Organization.ValueString(), data.WorkspaceName.ValueString(), err))
 		return
 	}
 
+	data.WorkspaceId = types.StringValue(ws.ID)
+
 	return
 }
 
-func getS3ObjectContents(ctx context.Context, client *s3.Client, bucket string, key string) (contents []byte, diag diag.Diagnostics) {
-	resp, err := client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
-	if err != nil {
-		diag.AddError("s3 client", fmt.Sprintf("failed to get object: %s", err))
-		return
-	}
-	defer resp.Body.Close()
+// writePlaceholder writes the configured placeholder for a workspace without
+// state and sets the computed attributes of data.
+func (r *S3ObjectResource) writePlaceholder(ctx context.Context, data *S3ObjectResourceModel, tags map[string]string) (diag diag.Diagnostics) {
+	data.PlaceholderWritten = types.BoolValue(false)
 
-	contents, err = io.ReadAll(resp.Body)
-	if err != nil {
-		diag.AddError("s3 client", fmt.Sprintf("failed to read body: %s", err))
-		return
+	now := time.Now()
+	key := data.Key.ValueString()
+
+	switch placeholderMode(data.Placeholder) {
+	case placeholderMarker:
+		marker, err := newPlaceholderMarker(data.WorkspaceId.ValueString(), now)
+		if err != nil {
+			diag.AddError("encoding", fmt.Sprintf("failed to encode placeholder: %s", err))
+			return
+		}
+
+		dst, d := r.destination(ctx, data)
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+
+		_, d = writeState(ctx, dst, key, stateEncoding{}, marker, destinationMetadata{WorkspaceId: data.WorkspaceId.ValueString(), Tags: tags})
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+
+		data.BucketContentsSha256 = sha256Contents(marker)
+		data.PlaceholderWritten = types.BoolValue(true)
+
+	case placeholderTags:
+		if len(tags) >= s3MaxTags {
+			diag.AddAttributeError(path.Root("tags"), "placeholder", fmt.Sprintf("the %s placeholder adds the %s tag, so at most %d tags may be set", placeholderTags, placeholderTagKey, s3MaxTags-1))
+			return
+		}
+
+		client, d := r.client(ctx, data)
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+
+		obj, d := newS3Destination(client, data.Bucket.ValueString(), data.KmsKeyId.ValueString(), r.uploads).head(ctx, key)
+		diag.Append(d...)
+		if diag.HasError() || obj == nil {
+			return
+		}
+
+		objectTags := make(map[string]string, len(tags)+1)
+		for k, v := range tags {
+			objectTags[k] = v
+		}
+		objectTags[placeholderTagKey] = placeholderTagValue(now)
+
+		diag.Append(putS3ObjectTags(ctx, client, data.Bucket.ValueString(), key, objectTags)...)
+		if diag.HasError() {
+			return
+		}
+
+		data.PlaceholderWritten = types.BoolValue(true)
 	}
 
 	return
 }
 
-type putObjectOptions struct {
-	Bucket   string
-	Key      string
-	KmsKeyId string
-	Contents []byte
-	Tags     map[string]string
-}
-
-func (o *putObjectOptions) validate() (diag diag.Diagnostics) {
-	if o == nil {
-		diag.AddError("putObjectOptions", "nil receiver")
+func (r *S3ObjectResource) destination(ctx context.Context, data *S3ObjectResourceModel) (dst destination, diag diag.Diagnostics) {
+	client, diag := r.client(ctx, data)
+	if diag.HasError() {
 		return
 	}
-	if o.Bucket == "" {
-		diag.AddError("putObjectOptions", "empty bucket")
+
+	return newS3Destination(client, data.Bucket.ValueString(), data.KmsKeyId.ValueString(), r.uploads), diag
+}
+
+func (r *S3ObjectResource) client(ctx context.Context, data *S3ObjectResourceModel) (client *s3.Client, diag diag.Diagnostics) {
+	opts := s3ClientOptions{
+		Region:   data.Region.ValueString(),
+		Endpoint: data.Endpoint.ValueString(),
 	}
-	if o.Key == "" {
-		diag.AddError("putObjectOptions", "empty key")
+	if data.AssumeRole != nil {
+		opts.RoleArn = data.AssumeRole.RoleARN.ValueString()
+		opts.RoleExternalId = data.AssumeRole.ExternalId.ValueString()
+		opts.RoleSessionName = data.AssumeRole.SessionName.ValueString()
 	}
-	if len(o.Contents) == 0 {
-		diag.AddError("putObjectOptions", "empty contents")
+
+	client, err := r.s3Clients.forBucket(ctx, data.Bucket.ValueString(), opts)
+	if err != nil {
+		diag.AddError("s3 client", err.Error())
 	}
 
 	return
 }
 
-func putS3ObjectContents(ctx context.Context, client *s3.Client, o *putObjectOptions) (diag diag.Diagnostics) {
-	diag.Append(o.validate()...)
-	if diag.HasError() {
-		return
-	}
-
-	ctx = tflog.SetField(ctx, "bucket", o.Bucket)
-	ctx = tflog.SetField(ctx, "key", o.Key)
+func sha256Contents(contents []byte) basetypes.StringValue {
+	return types.StringValue(sha256Hex(contents))
+}
 
-	tflog.Debug(ctx, "tfsync putobject")
+func newS3ObjectResourceID(data *S3ObjectResourceModel) basetypes.StringValue {
+	return types.StringValue(fmt.Sprintf("%s/%s/%s", data.WorkspaceId.ValueString(), data.Bucket.ValueString(), data.Key.ValueString()))
+}
 
-	input := &s3.PutObjectInput{
-		Bucket:            aws.String(o.Bucket),
-		Key:               aws.String(o.Key),
-		Body:              io.NopCloser(bytes.NewReader(o.Contents)),
-		ContentLength:     aws.Int64(int64(len(o.Contents))),
-		ContentType:       aws.String("application/json"),
-		ChecksumAlgorithm: s3types.ChecksumAlgorithmSha256,
-	}
+func getStateFile(ctx context.Context, client *tfe.Client, cache *stateCache, workspaceId string, ignoreEmpty bool) (state []byte, diag diag.Diagnostics, ignored bool) {
+	_, state, diag, ignored = getCurrentStateVersion(ctx, client, cache, workspaceId, ignoreEmpty)
+	return
+}
 
-	if o.KmsKeyId != "" {
-		input.ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
-		input.SSEKMSKeyId = aws.String(o.KmsKeyId)
-	}
+// getCurrentStateVersion is getStateFile, also returning the state version
+// the state was downloaded from.
+func getCurrentStateVersion(ctx context.Context, client *tfe.Client, cache *stateCache, workspaceId string, ignoreEmpty bool) (ver *tfe.StateVersion, state []byte, diag diag.Diagnostics, ignored bool) {
+	ver, err := client.StateVersions.ReadCurrent(ctx, workspaceId)
+	if err != nil {
+		if ignoreEmpty && errors.Is(err, tfe.ErrResourceNotFound) {
+			ignored = true
+			return
+		}
 
-	if len(o.Tags) > 0 {
-		input.Tagging = aws.String(newTags(o.Tags))
+		diag.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", err))
+		return
 	}
 
-	_, err := client.PutObject(ctx, input)
+	state, err = downloadStateVersion(ctx, client, cache, ver)
 	if err != nil {
-		diag.AddError("s3 client", fmt.Sprintf("failed s3 put object: %s", err))
+		diag.AddError("tfe client", fmt.Sprintf("failed to download state: %s", err))
 		return
 	}
 
 	return
 }
 
+// downloadStateVersion downloads the contents of ver through cache.
+func downloadStateVersion(ctx context.Context, client *tfe.Client, cache *stateCache, ver *tfe.StateVersion) ([]byte, error) {
+	return cache.get(ctx, ver.ID, func(ctx context.Context) ([]byte, error) {
+		return client.StateVersions.Download(ctx, ver.DownloadURL)
+	})
+}
+
 func validateS3ObjectResource(r *S3ObjectResource) (diag diag.Diagnostics) {
 	if r == nil {
 		diag.AddError("provider", "nil receiver")
 		return
 	}
 
-	if r.s3Client == nil {
+	if r.s3Clients == nil {
 		diag.AddError("provider", "nil s3 client")
 		return
 	}
@@ -437,16 +801,8 @@ func validateS3ObjectResource(r *S3ObjectResource) (diag diag.Diagnostics) {
 		return
 	}
 
-	return
-}
-
-func deleteS3Object(ctx context.Context, client *s3.Client, bucket string, key string) (diag diag.Diagnostics) {
-	_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
-	if err != nil {
-		diag.AddError("s3 client", fmt.Sprintf("failed to delete s3 object: %s", err))
+	if r.workspaces == nil {
+		diag.AddError("provider", "nil workspace list cache")
 		return
 	}
 
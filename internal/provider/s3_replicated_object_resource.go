@@ -0,0 +1,550 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &S3ReplicatedObjectResource{}
+
+func NewS3ReplicatedObjectResource() resource.Resource {
+	return &S3ReplicatedObjectResource{}
+}
+
+type S3ReplicatedObjectResource struct {
+	softDelete bool
+	tfeClient  *tfe.Client
+	s3Clients  *s3ClientCache
+	stateCache *stateCache
+	uploads    semaphore
+}
+
+type S3ReplicatedObjectResourceModel struct {
+	Id                  types.String     `tfsdk:"id"`
+	WorkspaceId         types.String     `tfsdk:"workspace_id"`
+	Destinations        []s3ReplicaModel `tfsdk:"destination"`
+	StateVersionId      types.String     `tfsdk:"state_version_id"`
+	Serial              types.Int64      `tfsdk:"serial"`
+	StateContentsSha256 types.String     `tfsdk:"state_contents_sha256"`
+	IgnoreEmpty         types.Bool       `tfsdk:"ignore_empty"`
+	Ignored             types.Bool       `tfsdk:"ignored"`
+	SoftDelete          types.Bool       `tfsdk:"soft_delete"`
+	Tags                types.Map        `tfsdk:"tags"`
+	Timeouts            timeouts.Value   `tfsdk:"timeouts"`
+}
+
+// s3ReplicaModel is one destination the state is replicated to.
+type s3ReplicaModel struct {
+	Bucket         types.String `tfsdk:"bucket"`
+	Key            types.String `tfsdk:"key"`
+	Region         types.String `tfsdk:"region"`
+	KmsKeyId       types.String `tfsdk:"kms_key_id"`
+	AccessKey      types.String `tfsdk:"access_key"`
+	SecretKey      types.String `tfsdk:"secret_key"`
+	RoleArn        types.String `tfsdk:"role_arn"`
+	ContentsSha256 types.String `tfsdk:"contents_sha256"`
+}
+
+func (r *S3ReplicatedObjectResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_s3_replicated_object"
+}
+
+func (r *S3ReplicatedObjectResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to sync tf-state to several s3 objects, e.g. in different regions or accounts. The state is downloaded once and the same contents are uploaded to every destination",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "workspace id, bucket and key of the first destination",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+			},
+			"state_version_id": schema.StringAttribute{
+				MarkdownDescription: "id of the state version last replicated",
+				Computed:            true,
+			},
+			"serial": schema.Int64Attribute{
+				MarkdownDescription: "serial of the state version last replicated",
+				Computed:            true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state",
+				Computed:            true,
+			},
+			"ignore_empty": schema.BoolAttribute{
+				MarkdownDescription: "ignore if no state is found",
+				Optional:            true,
+			},
+			"ignored": schema.BoolAttribute{
+				MarkdownDescription: "true if this was ignored due to no state file found and `ignore_empty` is enabled",
+				Computed:            true,
+			},
+			"soft_delete": schema.BoolAttribute{
+				MarkdownDescription: "use soft delete. Also keeps the objects of destinations removed from the configuration",
+				Optional:            true,
+			},
+			"tags": schema.MapAttribute{
+				MarkdownDescription: "tags applied to every object",
+				Optional:            true,
+				ElementType:         types.StringType,
+				Validators:          s3TagsValidators(),
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"destination": schema.ListNestedBlock{
+				MarkdownDescription: "s3 object to replicate the state to. At least one is required",
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"bucket": schema.StringAttribute{
+							MarkdownDescription: "s3 bucket",
+							Required:            true,
+							Validators:          s3BucketValidators(),
+						},
+						"key": schema.StringAttribute{
+							MarkdownDescription: "s3 bucket key",
+							Required:            true,
+							Validators:          s3KeyValidators(),
+						},
+						"region": schema.StringAttribute{
+							MarkdownDescription: "aws region of the bucket. Discovered from the bucket when unset",
+							Optional:            true,
+							Validators:          awsRegionValidators(),
+						},
+						"kms_key_id": schema.StringAttribute{
+							MarkdownDescription: "kms key id",
+							Optional:            true,
+							Validators:          kmsKeyIdValidators(),
+						},
+						"access_key": schema.StringAttribute{
+							MarkdownDescription: "aws access key id used instead of the provider's credentials",
+							Optional:            true,
+							Sensitive:           true,
+							Validators: []validator.String{
+								stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("secret_key")),
+							},
+						},
+						"secret_key": schema.StringAttribute{
+							MarkdownDescription: "aws secret access key used instead of the provider's credentials",
+							Optional:            true,
+							Sensitive:           true,
+							Validators: []validator.String{
+								stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("access_key")),
+							},
+						},
+						"role_arn": schema.StringAttribute{
+							MarkdownDescription: "iam role to assume for this destination, e.g. one in the account owning the bucket",
+							Optional:            true,
+							Validators:          iamRoleArnValidators(),
+						},
+						"contents_sha256": schema.StringAttribute{
+							MarkdownDescription: "sha256 sum of the object contents, or null if the last sync failed to write it",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (r *S3ReplicatedObjectResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.softDelete = data.softDelete
+	r.tfeClient = data.tfeClient
+	r.s3Clients = data.s3Clients
+	r.stateCache = data.stateCache
+	r.uploads = data.uploads
+}
+
+func (r *S3ReplicatedObjectResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	resp.Diagnostics.Append(validateS3ReplicatedObjectResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data S3ReplicatedObjectResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := data.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	d, replicated := r.sync(ctx, &data)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() && !replicated {
+		return
+	}
+
+	// Failed replicas are saved with a null contents_sha256, so that the
+	// objects written to the others are tracked.
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *S3ReplicatedObjectResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	resp.Diagnostics.Append(validateS3ReplicatedObjectResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data S3ReplicatedObjectResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := data.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	state, d, ignored := getStateFile(ctx, r.tfeClient, r.stateCache, data.WorkspaceId.ValueString(), data.IgnoreEmpty.ValueBool())
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = newS3ReplicatedObjectResourceID(&data)
+	data.Ignored = types.BoolValue(ignored)
+
+	if ignored {
+		data.clear()
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	data.StateContentsSha256 = sha256Contents(state)
+
+	// A missing replica recreates the resource, which uploads to every
+	// destination again.
+	for i := range data.Destinations {
+		replica := &data.Destinations[i]
+
+		dst, d := r.destination(ctx, replica)
+		resp.Diagnostics.Append(d...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		contents, d := getState(ctx, dst, replica.Key.ValueString(), stateEncoding{})
+		resp.Diagnostics.Append(d...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		if contents == nil {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		replica.ContentsSha256 = sha256Contents(contents)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *S3ReplicatedObjectResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	resp.Diagnostics.Append(validateS3ReplicatedObjectResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan, prior S3ReplicatedObjectResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := plan.Timeouts.Update(ctx, defaultUpdateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	d, replicated := r.sync(ctx, &plan)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() && !replicated {
+		return
+	}
+
+	// As on create, failed replicas are saved with a null contents_sha256.
+	// The objects of removed destinations are kept until a sync succeeds.
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if r.softDelete || plan.SoftDelete.ValueBool() {
+		return
+	}
+
+	// Remove the objects of destinations no longer configured.
+	for i := range prior.Destinations {
+		replica := &prior.Destinations[i]
+		if plan.hasDestination(replica) {
+			continue
+		}
+
+		dst, d := r.destination(ctx, replica)
+		if d.HasError() {
+			resp.Diagnostics.Append(d...)
+			continue
+		}
+		resp.Diagnostics.Append(dst.delete(ctx, replica.Key.ValueString())...)
+	}
+}
+
+func (r *S3ReplicatedObjectResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	resp.Diagnostics.Append(validateS3ReplicatedObjectResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data S3ReplicatedObjectResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := data.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if r.softDelete || data.SoftDelete.ValueBool() {
+		var objects []string
+		for _, replica := range data.Destinations {
+			objects = append(objects, replica.String())
+		}
+
+		resp.Diagnostics.AddWarning("using soft delete", fmt.Sprintf("objects: %s", strings.Join(objects, ", ")))
+		return
+	}
+
+	for i := range data.Destinations {
+		replica := &data.Destinations[i]
+		dst, d := r.destination(ctx, replica)
+		if d.HasError() {
+			resp.Diagnostics.Append(d...)
+			continue
+		}
+		resp.Diagnostics.Append(dst.delete(ctx, replica.Key.ValueString())...)
+	}
+}
+
+// sync downloads the workspace's current state once, uploads it to every
+// destination concurrently and sets the computed attributes of data. Every
+// destination is attempted even if another fails; replicated reports whether
+// any was, in which case data describes the replicas written even if diag
+// holds the failures of others.
+func (r *S3ReplicatedObjectResource) sync(ctx context.Context, data *S3ReplicatedObjectResourceModel) (diag diag.Diagnostics, replicated bool) {
+	var tags map[string]string
+	diag.Append(data.Tags.ElementsAs(ctx, &tags, true)...)
+	if diag.HasError() {
+		return
+	}
+
+	ver, state, d, ignored := getCurrentStateVersion(ctx, r.tfeClient, r.stateCache, data.WorkspaceId.ValueString(), data.IgnoreEmpty.ValueBool())
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	data.Id = newS3ReplicatedObjectResourceID(data)
+	data.Ignored = types.BoolValue(ignored)
+
+	if ignored {
+		data.clear()
+		return
+	}
+
+	metadata := destinationMetadata{WorkspaceId: data.WorkspaceId.ValueString(), Tags: tags}
+	var failures []string
+	for i, d := range r.replicate(ctx, data.Destinations, state, metadata) {
+		replica := &data.Destinations[i]
+
+		diag.Append(d.Warnings()...)
+
+		if d.HasError() {
+			var details []string
+			for _, e := range d.Errors() {
+				details = append(details, e.Detail())
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s", replica, strings.Join(details, "; ")))
+			replica.ContentsSha256 = types.StringNull()
+			continue
+		}
+
+		replica.ContentsSha256 = sha256Contents(state)
+	}
+
+	data.StateVersionId = types.StringValue(ver.ID)
+	data.Serial = types.Int64Value(ver.Serial)
+	data.StateContentsSha256 = sha256Contents(state)
+
+	if len(failures) > 0 {
+		diag.AddError("replication", fmt.Sprintf("failed to replicate state to %d of %d destinations:\n%s", len(failures), len(data.Destinations), strings.Join(failures, "\n")))
+	}
+
+	return diag, true
+}
+
+// replicate uploads state to every replica concurrently, returning the
+// diagnostics of each upload in the order of replicas.
+func (r *S3ReplicatedObjectResource) replicate(ctx context.Context, replicas []s3ReplicaModel, state []byte, metadata destinationMetadata) []diag.Diagnostics {
+	results := make([]diag.Diagnostics, len(replicas))
+
+	var wg sync.WaitGroup
+	for i := range replicas {
+		replica := &replicas[i]
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dst, d := r.destination(ctx, replica)
+			if d.HasError() {
+				results[i] = d
+				return
+			}
+			_, results[i] = putState(ctx, dst, replica.Key.ValueString(), stateEncoding{}, state, metadata)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (r *S3ReplicatedObjectResource) destination(ctx context.Context, replica *s3ReplicaModel) (dst destination, diag diag.Diagnostics) {
+	client, err := r.s3Clients.forBucket(ctx, replica.Bucket.ValueString(), s3ClientOptions{
+		Region:    replica.Region.ValueString(),
+		AccessKey: replica.AccessKey.ValueString(),
+		SecretKey: replica.SecretKey.ValueString(),
+		RoleArn:   replica.RoleArn.ValueString(),
+	})
+	if err != nil {
+		diag.AddError("s3 client", err.Error())
+		return
+	}
+
+	return newS3Destination(client, replica.Bucket.ValueString(), replica.KmsKeyId.ValueString(), r.uploads), diag
+}
+
+// clear nulls the computed attributes of an ignored workspace.
+func (m *S3ReplicatedObjectResourceModel) clear() {
+	m.StateVersionId = types.StringNull()
+	m.Serial = types.Int64Null()
+	m.StateContentsSha256 = types.StringNull()
+
+	for i := range m.Destinations {
+		m.Destinations[i].ContentsSha256 = types.StringNull()
+	}
+}
+
+// hasDestination reports whether m still replicates to the object of replica.
+func (m *S3ReplicatedObjectResourceModel) hasDestination(replica *s3ReplicaModel) bool {
+	for _, other := range m.Destinations {
+		if other.Bucket.Equal(replica.Bucket) && other.Key.Equal(replica.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m s3ReplicaModel) String() string {
+	return fmt.Sprintf("s3://%s/%s", m.Bucket.ValueString(), m.Key.ValueString())
+}
+
+func newS3ReplicatedObjectResourceID(data *S3ReplicatedObjectResourceModel) types.String {
+	if len(data.Destinations) == 0 {
+		return data.WorkspaceId
+	}
+
+	first := data.Destinations[0]
+	return types.StringValue(fmt.Sprintf("%s/%s/%s", data.WorkspaceId.ValueString(), first.Bucket.ValueString(), first.Key.ValueString()))
+}
+
+func validateS3ReplicatedObjectResource(r *S3ReplicatedObjectResource) (diag diag.Diagnostics) {
+	if r == nil {
+		diag.AddError("provider", "nil receiver")
+		return
+	}
+
+	if r.s3Clients == nil {
+		diag.AddError("provider", "nil s3 client")
+		return
+	}
+
+	if r.tfeClient == nil {
+		diag.AddError("provider", "nil tfe client")
+		return
+	}
+
+	return
+}
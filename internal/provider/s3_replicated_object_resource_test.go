@@ -0,0 +1,167 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// fakeS3Objects stores objects by path, places every bucket in us-east-1
+// and denies access to the bucket "denied". checksums replaces the sha256 checksum of objects, e.g. to
+// simulate corruption.
+type fakeS3Objects struct {
+	mu        sync.Mutex
+	objects   map[string][]byte
+	checksums map[string]string
+}
+
+func (f *fakeS3Objects) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/denied/") {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodHead && strings.Count(strings.Trim(r.URL.Path, "/"), "/") == 0:
+		w.Header().Set("X-Amz-Bucket-Region", "us-east-1")
+
+	case r.Method == http.MethodPut:
+		body, err := readAWSChunked(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+
+	case r.Method == http.MethodHead, r.Method == http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		checksum := base64.StdEncoding.EncodeToString(sum[:])
+		if c, ok := f.checksums[r.URL.Path]; ok {
+			checksum = c
+		}
+		w.Header().Set("x-amz-checksum-sha256", checksum)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(body)
+		}
+
+	default:
+		http.Error(w, "unexpected request", http.StatusNotImplemented)
+	}
+}
+
+// readAWSChunked reads the body of r, decoding the aws-chunked encoding the
+// sdk uses to send checksums as trailers.
+func readAWSChunked(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return io.ReadAll(r.Body)
+	}
+
+	var body []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSpace(strings.Split(line, ";")[0]), 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body, nil
+		}
+
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, err
+		}
+		body = append(body, chunk[:size]...)
+	}
+}
+
+// newFakeS3Client returns the aws configuration and a client for a server
+// serving f.
+func newFakeS3Client(t *testing.T, f *fakeS3Objects) (aws.Config, *s3.Client) {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(f)
+	t.Cleanup(srv.Close)
+
+	base := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("test", "test", ""),
+		HTTPClient:  srv.Client(),
+	}
+	client := s3.NewFromConfig(base, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(srv.URL)
+		o.UsePathStyle = true
+	})
+
+	return base, client
+}
+
+func TestS3ReplicatedObjectPartialFailure(t *testing.T) {
+	base, client := newFakeS3Client(t, &fakeS3Objects{objects: make(map[string][]byte)})
+
+	r := &S3ReplicatedObjectResource{
+		tfeClient:  newFakeTfeClient(t),
+		s3Clients:  newS3ClientCache(base, client),
+		stateCache: newStateCache(1<<20, ""),
+	}
+
+	replica := func(bucket string) s3ReplicaModel {
+		return s3ReplicaModel{
+			Bucket: types.StringValue(bucket),
+			Key:    types.StringValue("terraform.tfstate"),
+			Region: types.StringValue("us-east-1"),
+		}
+	}
+	data := &S3ReplicatedObjectResourceModel{
+		WorkspaceId:  types.StringValue("ws-abc"),
+		Destinations: []s3ReplicaModel{replica("allowed"), replica("denied")},
+		Tags:         types.MapNull(types.StringType),
+	}
+
+	diags, replicated := r.sync(context.Background(), data)
+	if !diags.HasError() || !replicated {
+		t.Fatalf("got replicated %t and %v, want a replication error", replicated, diags)
+	}
+
+	want := sha256Contents([]byte(fakeTfeState))
+	if got := data.Destinations[0].ContentsSha256; !got.Equal(want) {
+		t.Errorf("got contents_sha256 %s for the written replica, want %s", got, want)
+	}
+	if got := data.Destinations[1].ContentsSha256; !got.IsNull() {
+		t.Errorf("got contents_sha256 %s for the failed replica, want null", got)
+	}
+	if data.StateVersionId.ValueString() != "sv-abc" || !data.StateContentsSha256.Equal(want) {
+		t.Errorf("got state version %s and checksum %s, want sv-abc and %s", data.StateVersionId, data.StateContentsSha256, want)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// stateFile is the subset of the terraform state file format (version 4)
+// read by the provider.
+type stateFile struct {
+	Version          int                        `json:"version"`
+	TerraformVersion string                     `json:"terraform_version"`
+	Serial           int64                      `json:"serial"`
+	Lineage          string                     `json:"lineage"`
+	Outputs          map[string]stateFileOutput `json:"outputs"`
+	Resources        []stateFileResource        `json:"resources"`
+}
+
+type stateFileOutput struct {
+	Value     json.RawMessage `json:"value"`
+	Type      json.RawMessage `json:"type"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+type stateFileResource struct {
+	Module    string                      `json:"module,omitempty"`
+	Mode      string                      `json:"mode"`
+	Type      string                      `json:"type"`
+	Name      string                      `json:"name"`
+	Instances []stateFileResourceInstance `json:"instances"`
+}
+
+type stateFileResourceInstance struct {
+	IndexKey any `json:"index_key,omitempty"`
+}
+
+func parseStateFile(contents []byte) (*stateFile, error) {
+	var s stateFile
+	if err := json.Unmarshal(contents, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	if s.Version != 4 {
+		return nil, fmt.Errorf("unsupported state version %d", s.Version)
+	}
+
+	return &s, nil
+}
+
+// resourceCount returns the number of managed resource instances.
+func (s *stateFile) resourceCount() int {
+	var n int
+	for _, r := range s.Resources {
+		if r.Mode == "managed" {
+			n += len(r.Instances)
+		}
+	}
+	return n
+}
+
+// resourceAddresses returns the address of every resource instance, e.g.
+// module.network.aws_subnet.private["a"].
+func (s *stateFile) resourceAddresses() []string {
+	addrs := []string{}
+	for _, r := range s.Resources {
+		var b strings.Builder
+		if r.Module != "" {
+			b.WriteString(r.Module)
+			b.WriteByte('.')
+		}
+		if r.Mode == "data" {
+			b.WriteString("data.")
+		}
+		b.WriteString(r.Type)
+		b.WriteByte('.')
+		b.WriteString(r.Name)
+
+		base := b.String()
+		for _, i := range r.Instances {
+			switch k := i.IndexKey.(type) {
+			case nil:
+				addrs = append(addrs, base)
+			case float64:
+				addrs = append(addrs, fmt.Sprintf("%s[%d]", base, int64(k)))
+			case string:
+				addrs = append(addrs, fmt.Sprintf("%s[%s]", base, strconv.Quote(k)))
+			default:
+				addrs = append(addrs, fmt.Sprintf("%s[%v]", base, k))
+			}
+		}
+	}
+	return addrs
+}
+
+// withSerial returns contents with the state serial replaced, leaving every
+// other field as written.
+func withSerial(contents []byte, serial int64) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	fields["serial"] = json.RawMessage(strconv.FormatInt(serial, 10))
+
+	return json.MarshalIndent(fields, "", "  ")
+}
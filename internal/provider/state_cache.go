@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultStateCacheMaxMemoryMB = 64
+
+	// stateCacheFetchTimeout bounds a download shared by several callers,
+	// which runs independently of any one caller's context. Downloading even
+	// a large state takes seconds, so this only stops a stalled download from
+	// holding its callers until their own timeouts, and from leaking once
+	// they have all given up.
+	stateCacheFetchTimeout = 10 * time.Minute
+)
+
+// spillDirs holds the spill directories of every cache, removed by
+// RemoveSpilledState when the provider stops.
+var spillDirs struct {
+	mu   sync.Mutex
+	dirs []string
+}
+
+// stateCache holds downloaded state files keyed by state version ID so that
+// every resource in a run shares a single download per state version.
+//
+// Entries are kept in memory up to maxBytes and evicted least recently used
+// first. When spillDir is set, evicted entries and entries too large for
+// memory are written to a private temporary directory instead of dropped.
+// The directory is removed by RemoveSpilledState.
+type stateCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	lru      *list.List
+	entries  map[string]*list.Element
+	spillDir string
+	tempDir  string
+	spilled  map[string]string
+	group    singleflight.Group
+}
+
+type stateCacheEntry struct {
+	id       string
+	contents []byte
+}
+
+func newStateCache(maxBytes int64, spillDir string) *stateCache {
+	return &stateCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		spillDir: spillDir,
+		spilled:  make(map[string]string),
+	}
+}
+
+// get returns the contents of the state version with the given id, calling
+// fetch at most once across concurrent callers when it is not cached. fetch
+// is not cancelled when a caller's ctx is, so that one caller giving up does
+// not fail the others.
+func (c *stateCache) get(ctx context.Context, id string, fetch func(context.Context) ([]byte, error)) ([]byte, error) {
+	if c == nil {
+		return fetch(ctx)
+	}
+
+	ch := c.group.DoChan(id, func() (any, error) {
+		if contents, ok := c.lookup(ctx, id); ok {
+			tflog.Debug(ctx, "tfsync state cache hit", map[string]any{"state_version_id": id})
+			return contents, nil
+		}
+
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), stateCacheFetchTimeout)
+		defer cancel()
+
+		contents, err := fetch(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.store(ctx, id, contents)
+
+		return contents, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil //nolint:forcetypeassert
+	}
+}
+
+func (c *stateCache) lookup(ctx context.Context, id string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[id]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*stateCacheEntry).contents, true //nolint:forcetypeassert
+	}
+
+	name, ok := c.spilled[id]
+	if !ok {
+		return nil, false
+	}
+
+	contents, err := os.ReadFile(name)
+	if err != nil {
+		tflog.Warn(ctx, "tfsync state cache failed to read spilled state", map[string]any{"state_version_id": id, "error": err.Error()})
+		delete(c.spilled, id)
+		return nil, false
+	}
+
+	return contents, true
+}
+
+func (c *stateCache) store(ctx context.Context, id string, contents []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	size := int64(len(contents))
+	if size > c.maxBytes {
+		c.spill(ctx, id, contents)
+		return
+	}
+
+	c.entries[id] = c.lru.PushFront(&stateCacheEntry{id: id, contents: contents})
+	c.size += size
+
+	for c.size > c.maxBytes {
+		e := c.lru.Back()
+		entry := e.Value.(*stateCacheEntry) //nolint:forcetypeassert
+
+		c.lru.Remove(e)
+		delete(c.entries, entry.id)
+		c.size -= int64(len(entry.contents))
+
+		c.spill(ctx, entry.id, entry.contents)
+	}
+}
+
+// spill writes contents to the spill directory. It must be called with c.mu
+// held. Failures are logged and the entry is dropped, since the cache is
+// only an optimisation.
+func (c *stateCache) spill(ctx context.Context, id string, contents []byte) {
+	if c.spillDir == "" {
+		return
+	}
+
+	if c.tempDir == "" {
+		dir, err := os.MkdirTemp(c.spillDir, "tfsync-state-cache-")
+		if err != nil {
+			tflog.Warn(ctx, "tfsync state cache failed to create spill directory", map[string]any{"error": err.Error()})
+			return
+		}
+		c.tempDir = dir
+
+		spillDirs.mu.Lock()
+		spillDirs.dirs = append(spillDirs.dirs, dir)
+		spillDirs.mu.Unlock()
+	}
+
+	name := filepath.Join(c.tempDir, fmt.Sprintf("%s.tfstate", filepath.Base(id)))
+	if err := os.WriteFile(name, contents, 0o600); err != nil {
+		tflog.Warn(ctx, "tfsync state cache failed to spill state", map[string]any{"state_version_id": id, "error": err.Error()})
+		return
+	}
+
+	c.spilled[id] = name
+}
+
+// RemoveSpilledState removes the spill directories of all state caches. It is
+// called when the provider stops, so that no state file outlives it.
+func RemoveSpilledState() error {
+	spillDirs.mu.Lock()
+	defer spillDirs.mu.Unlock()
+
+	var errs []error
+	for _, dir := range spillDirs.dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	spillDirs.dirs = nil
+
+	return errors.Join(errs...)
+}
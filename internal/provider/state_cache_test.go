@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStateCacheCallerCancel(t *testing.T) {
+	c := newStateCache(1<<20, "")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	fetch := func(ctx context.Context) ([]byte, error) {
+		fetches.Add(1)
+		close(started)
+		select {
+		case <-release:
+			return []byte("state"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// The first caller starts the download and gives up on it.
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.get(ctx, "sv-1", fetch)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan []byte, 1)
+	go func() {
+		contents, err := c.get(context.Background(), "sv-1", fetch)
+		if err != nil {
+			t.Errorf("second caller: %s", err)
+		}
+		second <- contents
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller: got %v, want context.Canceled", err)
+	}
+
+	close(release)
+	select {
+	case contents := <-second:
+		if string(contents) != "state" {
+			t.Errorf("got %q, want %q", contents, "state")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second caller did not return")
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("got %d fetches, want 1", n)
+	}
+}
+
+func TestStateCacheSpill(t *testing.T) {
+	c := newStateCache(4, t.TempDir())
+
+	fetch := func(ctx context.Context) ([]byte, error) {
+		return []byte("larger than memory"), nil
+	}
+	if _, err := c.get(context.Background(), "sv-1", fetch); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := c.get(context.Background(), "sv-1", func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("spilled state was not read")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "larger than memory" {
+		t.Errorf("got %q, want %q", contents, "larger than memory")
+	}
+
+	if err := RemoveSpilledState(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.tempDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("spill directory %s was not removed: %v", c.tempDir, err)
+	}
+}
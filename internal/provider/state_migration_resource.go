@@ -0,0 +1,593 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &StateMigrationResource{}
+var _ resource.ResourceWithModifyPlan = &StateMigrationResource{}
+
+const (
+	defaultStateMigrationCreateTimeout = 60 * time.Minute
+	defaultStateMigrationUpdateTimeout = 20 * time.Minute
+)
+
+func NewStateMigrationResource() resource.Resource {
+	return &StateMigrationResource{}
+}
+
+type StateMigrationResource struct {
+	maxRetries int
+	downloads  semaphore
+
+	// clients holds the tfe client of each host and token, so that an
+	// operation creates each client once.
+	mu      sync.Mutex
+	clients map[stateMigrationClientKey]*tfe.Client
+}
+
+type stateMigrationClientKey struct {
+	Hostname string
+	Token    string
+}
+
+type StateMigrationResourceModel struct {
+	Id                   types.String   `tfsdk:"id"`
+	Source               types.Object   `tfsdk:"source"`
+	Destination          types.Object   `tfsdk:"destination"`
+	History              types.Bool     `tfsdk:"history"`
+	LockSource           types.Bool     `tfsdk:"lock_source"`
+	AllowLineageMismatch types.Bool     `tfsdk:"allow_lineage_mismatch"`
+	SourceStateVersionId types.String   `tfsdk:"source_state_version_id"`
+	Migrated             types.List     `tfsdk:"migrated"`
+	StateContentsSha256  types.String   `tfsdk:"state_contents_sha256"`
+	Timeouts             timeouts.Value `tfsdk:"timeouts"`
+}
+
+// stateMigrationWorkspaceModel addresses a workspace on a tfe host.
+type stateMigrationWorkspaceModel struct {
+	Hostname      types.String `tfsdk:"hostname"`
+	Token         types.String `tfsdk:"token"`
+	Organization  types.String `tfsdk:"organization"`
+	WorkspaceName types.String `tfsdk:"workspace_name"`
+}
+
+func (m *stateMigrationWorkspaceModel) String() string {
+	if m.Hostname.ValueString() == "" {
+		return fmt.Sprintf("%s/%s", m.Organization.ValueString(), m.WorkspaceName.ValueString())
+	}
+	return fmt.Sprintf("%s/%s/%s", m.Hostname.ValueString(), m.Organization.ValueString(), m.WorkspaceName.ValueString())
+}
+
+type stateMigrationVersionModel struct {
+	SourceStateVersionId types.String `tfsdk:"source_state_version_id"`
+	StateVersionId       types.String `tfsdk:"state_version_id"`
+	Serial               types.Int64  `tfsdk:"serial"`
+}
+
+var stateMigrationVersionAttrTypes = map[string]attr.Type{
+	"source_state_version_id": types.StringType,
+	"state_version_id":        types.StringType,
+	"serial":                  types.Int64Type,
+}
+
+func (r *StateMigrationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_state_migration"
+}
+
+func stateMigrationWorkspaceAttribute(description string) schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		MarkdownDescription: description,
+		Required:            true,
+		Attributes: map[string]schema.Attribute{
+			"hostname": schema.StringAttribute{
+				MarkdownDescription: "tfe hostname, e.g. `app.terraform.io`. Defaults to the `TFE_HOSTNAME` or `TFE_ADDRESS` environment variables",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"token": schema.StringAttribute{
+				MarkdownDescription: "tfe api token. Defaults to the `TFE_TOKEN` environment variable",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"organization": schema.StringAttribute{
+				MarkdownDescription: "terraform organization",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"workspace_name": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace name",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+		},
+	}
+}
+
+func (r *StateMigrationResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to copy the tf-state of a workspace to a workspace in another organization or on another tfe host. The destination workspace is locked while each state version is uploaded, and the lineage of the source state is kept. Later changes to the source state are copied on the next apply. Destroying the resource does not change either workspace",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "source and destination workspaces",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"source":      stateMigrationWorkspaceAttribute("workspace to copy state from"),
+			"destination": stateMigrationWorkspaceAttribute("workspace to copy state to"),
+			"history": schema.BoolAttribute{
+				MarkdownDescription: "copy every state version of the source workspace, oldest first, instead of only the current one. Only applies to the first apply",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"lock_source": schema.BoolAttribute{
+				MarkdownDescription: "lock the source workspace while copying, so that no run changes its state mid-way. The workspace is unlocked again afterwards",
+				Optional:            true,
+			},
+			"allow_lineage_mismatch": schema.BoolAttribute{
+				MarkdownDescription: "copy even if the lineage of the source state differs from the lineage of the destination's current state",
+				Optional:            true,
+			},
+			"source_state_version_id": schema.StringAttribute{
+				MarkdownDescription: "current state version of the source workspace as of the last refresh. A change schedules the new state version to be copied",
+				Computed:            true,
+			},
+			"migrated": schema.ListNestedAttribute{
+				MarkdownDescription: "every state version copied, oldest first",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"source_state_version_id": schema.StringAttribute{
+							MarkdownDescription: "id of the state version in the source workspace",
+							Computed:            true,
+						},
+						"state_version_id": schema.StringAttribute{
+							MarkdownDescription: "id of the state version created in the destination workspace, null if the destination already had the state, e.g. from an earlier apply that failed part way",
+							Computed:            true,
+						},
+						"serial": schema.Int64Attribute{
+							MarkdownDescription: "serial of the created state version",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of the last tf state copied to the destination",
+				Computed:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+		},
+	}
+}
+
+func (r *StateMigrationResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.maxRetries = data.maxRetries
+	r.downloads = data.downloads
+}
+
+// ModifyPlan schedules an update when a refresh found a new source state
+// version, since migrated is computed and would otherwise never differ from
+// the configuration.
+func (r *StateMigrationResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var state StateMigrationResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	migrated, d := stateMigrationVersions(ctx, state.Migrated)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	last := types.StringNull()
+	if len(migrated) > 0 {
+		last = migrated[len(migrated)-1].SourceStateVersionId
+	}
+
+	if state.SourceStateVersionId.IsNull() || state.SourceStateVersionId.Equal(last) {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("migrated"), types.ListUnknown(types.ObjectType{AttrTypes: stateMigrationVersionAttrTypes}))...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("state_contents_sha256"), types.StringUnknown())...)
+}
+
+func (r *StateMigrationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data StateMigrationResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := data.Timeouts.Create(ctx, defaultStateMigrationCreateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// State versions copied before a failure are saved, so that they are
+	// not copied again.
+	resp.Diagnostics.Append(r.migrate(ctx, &data, nil)...)
+	if resp.Diagnostics.HasError() && len(data.Migrated.Elements()) == 0 {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *StateMigrationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data StateMigrationResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	source, d := stateMigrationWorkspace(ctx, data.Source)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, d := r.client(source)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ws, err := client.Workspaces.Read(ctx, source.Organization.ValueString(), source.WorkspaceName.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to read workspace %s: %s", source, err))
+		return
+	}
+
+	data.SourceStateVersionId = types.StringNull()
+	if id := currentStateVersionId(ws); id != "" {
+		data.SourceStateVersionId = types.StringValue(id)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *StateMigrationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state StateMigrationResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := plan.Timeouts.Update(ctx, defaultStateMigrationUpdateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp.Diagnostics.Append(r.migrate(ctx, &plan, &state)...)
+	if plan.Migrated.IsUnknown() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *StateMigrationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data StateMigrationResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.AddWarning("migration not undone", fmt.Sprintf("state copied by %s is kept in the destination workspace", data.Id.ValueString()))
+}
+
+// migrate copies the source state versions not yet copied according to
+// prior, which is nil on create. data records the versions copied so far also
+// when migrate fails part way.
+func (r *StateMigrationResource) migrate(ctx context.Context, data *StateMigrationResourceModel, prior *StateMigrationResourceModel) (diag diag.Diagnostics) {
+	source, d := stateMigrationWorkspace(ctx, data.Source)
+	diag.Append(d...)
+	destination, d := stateMigrationWorkspace(ctx, data.Destination)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	var migrated []stateMigrationVersionModel
+	data.Id = types.StringValue(fmt.Sprintf("%s->%s", source, destination))
+	data.SourceStateVersionId = types.StringNull()
+	data.StateContentsSha256 = types.StringNull()
+	if prior != nil {
+		migrated, d = stateMigrationVersions(ctx, prior.Migrated)
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+		data.SourceStateVersionId = prior.SourceStateVersionId
+		data.StateContentsSha256 = prior.StateContentsSha256
+	}
+
+	defer func() {
+		data.Migrated, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: stateMigrationVersionAttrTypes}, migrated)
+		diag.Append(d...)
+	}()
+
+	sourceClient, d := r.client(source)
+	diag.Append(d...)
+	destinationClient, d := r.client(destination)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	sourceWs, err := sourceClient.Workspaces.Read(ctx, source.Organization.ValueString(), source.WorkspaceName.ValueString())
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to read workspace %s: %s", source, err))
+		return
+	}
+
+	destinationWs, err := destinationClient.Workspaces.Read(ctx, destination.Organization.ValueString(), destination.WorkspaceName.ValueString())
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to read workspace %s: %s", destination, err))
+		return
+	}
+
+	if data.LockSource.ValueBool() {
+		_, err := sourceClient.Workspaces.Lock(ctx, sourceWs.ID, tfe.WorkspaceLockOptions{Reason: tfe.String(fmt.Sprintf("tfsync: migrating to %s", destination))})
+		if err != nil {
+			diag.AddError("tfe client", fmt.Sprintf("failed to lock workspace %s: %s", source, err))
+			return
+		}
+		defer func() {
+			if _, err := sourceClient.Workspaces.Unlock(context.WithoutCancel(ctx), sourceWs.ID); err != nil {
+				diag.AddError("tfe client", fmt.Sprintf("failed to unlock workspace %s: %s", source, err))
+			}
+		}()
+	}
+
+	history := prior == nil && data.History.ValueBool()
+
+	versions, d := sourceStateVersions(ctx, sourceClient, source, sourceWs, history)
+	diag.Append(d...)
+	if diag.HasError() {
+		return
+	}
+
+	data.SourceStateVersionId = types.StringNull()
+	if len(versions) > 0 {
+		data.SourceStateVersionId = types.StringValue(versions[len(versions)-1].ID)
+	}
+
+	// A create that failed part way leaves the resource tainted, so the
+	// retry is a create again. Versions the destination already has are
+	// skipped rather than copied twice.
+	var current *stateFile
+	if history {
+		current, d = currentDestinationState(ctx, destinationClient, destination, destinationWs)
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+	}
+
+	for _, ver := range versions {
+		if slices.ContainsFunc(migrated, func(m stateMigrationVersionModel) bool { return m.SourceStateVersionId.ValueString() == ver.ID }) {
+			continue
+		}
+
+		contents, err := downloadStateVersion(ctx, sourceClient, nil, ver)
+		if err != nil {
+			diag.AddError("tfe client", fmt.Sprintf("failed to download state version %s: %s", ver.ID, err))
+			return
+		}
+
+		if current != nil {
+			state, err := parseStateFile(contents)
+			if err == nil && state.Lineage == current.Lineage && state.Serial <= current.Serial {
+				tflog.Info(ctx, "tfsync skipping state version the destination already has", map[string]any{"state_version_id": ver.ID, "serial": state.Serial})
+				migrated = append(migrated, stateMigrationVersionModel{
+					SourceStateVersionId: types.StringValue(ver.ID),
+					StateVersionId:       types.StringNull(),
+					Serial:               types.Int64Value(state.Serial),
+				})
+				continue
+			}
+		}
+
+		result, d := uploadStateVersion(ctx, destinationClient, nil, &uploadStateOptions{
+			WorkspaceId:          destinationWs.ID,
+			Contents:             contents,
+			LockReason:           fmt.Sprintf("tfsync: migrating from %s", source),
+			AllowLineageMismatch: data.AllowLineageMismatch.ValueBool(),
+		})
+		diag.Append(d...)
+		if diag.HasError() {
+			return
+		}
+
+		migrated = append(migrated, stateMigrationVersionModel{
+			SourceStateVersionId: types.StringValue(ver.ID),
+			StateVersionId:       types.StringValue(result.StateVersion.ID),
+			Serial:               types.Int64Value(result.Serial),
+		})
+		data.StateContentsSha256 = sha256Contents(result.Contents)
+	}
+
+	return
+}
+
+// currentDestinationState returns the current state of the destination
+// workspace, or nil if it has none.
+func currentDestinationState(ctx context.Context, client *tfe.Client, destination *stateMigrationWorkspaceModel, ws *tfe.Workspace) (state *stateFile, diag diag.Diagnostics) {
+	ver, err := client.StateVersions.ReadCurrent(ctx, ws.ID)
+	switch {
+	case errors.Is(err, tfe.ErrResourceNotFound):
+		return
+	case err != nil:
+		diag.AddError("tfe client", fmt.Sprintf("failed to get state version of %s: %s", destination, err))
+		return
+	}
+
+	contents, err := downloadStateVersion(ctx, client, nil, ver)
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to download state of %s: %s", destination, err))
+		return
+	}
+
+	state, err = parseStateFile(contents)
+	if err != nil {
+		diag.AddError("state", fmt.Sprintf("%s: %s", destination, err))
+		return
+	}
+
+	return
+}
+
+// sourceStateVersions returns the current state version of the source
+// workspace, or every state version oldest first when history is set.
+func sourceStateVersions(ctx context.Context, client *tfe.Client, source *stateMigrationWorkspaceModel, ws *tfe.Workspace, history bool) (versions []*tfe.StateVersion, diag diag.Diagnostics) {
+	if !history {
+		ver, err := client.StateVersions.ReadCurrent(ctx, ws.ID)
+		switch {
+		case errors.Is(err, tfe.ErrResourceNotFound):
+			// Nothing to copy until the source workspace has state.
+		case err != nil:
+			diag.AddError("tfe client", fmt.Sprintf("failed to get state version of %s: %s", source, err))
+		default:
+			versions = append(versions, ver)
+		}
+		return
+	}
+
+	opts := &tfe.StateVersionListOptions{
+		ListOptions:  tfe.ListOptions{PageSize: 100},
+		Organization: source.Organization.ValueString(),
+		Workspace:    source.WorkspaceName.ValueString(),
+	}
+	for {
+		list, err := client.StateVersions.List(ctx, opts)
+		if err != nil {
+			diag.AddError("tfe client", fmt.Sprintf("failed to list state versions of %s: %s", source, err))
+			return
+		}
+
+		versions = append(versions, list.Items...)
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			break
+		}
+		opts.PageNumber = list.NextPage
+	}
+
+	// State versions are listed newest first.
+	slices.Reverse(versions)
+
+	return
+}
+
+// client returns the tfe client for the host and token of ws, creating it on
+// first use.
+func (r *StateMigrationResource) client(ws *stateMigrationWorkspaceModel) (client *tfe.Client, diag diag.Diagnostics) {
+	key := stateMigrationClientKey{Hostname: ws.Hostname.ValueString(), Token: ws.Token.ValueString()}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client, ok := r.clients[key]; ok {
+		return client, diag
+	}
+
+	client, err := newTfeClient(key.Hostname, key.Token, r.maxRetries, r.downloads)
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to create tfe client for %s: %s", ws.Hostname.ValueString(), err))
+		return
+	}
+
+	if r.clients == nil {
+		r.clients = make(map[stateMigrationClientKey]*tfe.Client)
+	}
+	r.clients[key] = client
+
+	return
+}
+
+func stateMigrationWorkspace(ctx context.Context, o types.Object) (ws *stateMigrationWorkspaceModel, diag diag.Diagnostics) {
+	ws = &stateMigrationWorkspaceModel{}
+	diag.Append(o.As(ctx, ws, basetypes.ObjectAsOptions{})...)
+
+	return
+}
+
+func stateMigrationVersions(ctx context.Context, l types.List) (versions []stateMigrationVersionModel, diag diag.Diagnostics) {
+	if l.IsNull() || l.IsUnknown() {
+		return
+	}
+
+	diag.Append(l.ElementsAs(ctx, &versions, false)...)
+
+	return
+}
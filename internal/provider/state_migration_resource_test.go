@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func newTestStateMigrationModel(t *testing.T, source string, destination string) *StateMigrationResourceModel {
+	t.Helper()
+
+	workspace := func(name string) types.Object {
+		o, diags := types.ObjectValueFrom(context.Background(), map[string]attr.Type{
+			"hostname":       types.StringType,
+			"token":          types.StringType,
+			"organization":   types.StringType,
+			"workspace_name": types.StringType,
+		}, stateMigrationWorkspaceModel{
+			Hostname:      types.StringNull(),
+			Token:         types.StringNull(),
+			Organization:  types.StringValue("acme"),
+			WorkspaceName: types.StringValue(name),
+		})
+		if diags.HasError() {
+			t.Fatal(diags)
+		}
+		return o
+	}
+
+	return &StateMigrationResourceModel{
+		Source:               workspace(source),
+		Destination:          workspace(destination),
+		History:              types.BoolValue(true),
+		LockSource:           types.BoolValue(true),
+		AllowLineageMismatch: types.BoolValue(false),
+		Migrated:             types.ListNull(types.ObjectType{AttrTypes: stateMigrationVersionAttrTypes}),
+	}
+}
+
+func testStateMigrationState(serial int) string {
+	return fmt.Sprintf(`{"version":4,"serial":%d,"lineage":"network"}`, serial)
+}
+
+func TestStateMigrationPartialProgress(t *testing.T) {
+	f, client := newFakeTfeStates(t, "old", "new")
+	for serial := 1; serial <= 3; serial++ {
+		f.addState("old", testStateMigrationState(serial))
+	}
+	f.failCreate[2] = true
+
+	r := &StateMigrationResource{clients: map[stateMigrationClientKey]*tfe.Client{{}: client}}
+	ctx := context.Background()
+
+	type version struct {
+		source, destination string
+		serial              int64
+	}
+	versions := func(data *StateMigrationResourceModel) []version {
+		t.Helper()
+		migrated, diags := stateMigrationVersions(ctx, data.Migrated)
+		if diags.HasError() {
+			t.Fatal(diags)
+		}
+		var got []version
+		for _, m := range migrated {
+			got = append(got, version{m.SourceStateVersionId.ValueString(), m.StateVersionId.ValueString(), m.Serial.ValueInt64()})
+		}
+		return got
+	}
+
+	// The versions copied before a failure are recorded, so that Create
+	// saves them.
+	data := newTestStateMigrationModel(t, "old", "new")
+	if diags := r.migrate(ctx, data, nil); !diags.HasError() {
+		t.Fatal("got no error for a failed upload")
+	}
+	if got, want := versions(data), []version{{"sv-old-1", "sv-new-1", 1}}; !slices.Equal(got, want) {
+		t.Errorf("got migrated %v after a failure, want %v", got, want)
+	}
+
+	// The failed create taints the resource, and its retry skips the version
+	// the destination already has.
+	delete(f.failCreate, 2)
+	data = newTestStateMigrationModel(t, "old", "new")
+	if diags := r.migrate(ctx, data, nil); diags.HasError() {
+		t.Fatal(diags)
+	}
+	want := []version{{"sv-old-1", "", 1}, {"sv-old-2", "sv-new-2", 2}, {"sv-old-3", "sv-new-3", 3}}
+	if got := versions(data); !slices.Equal(got, want) {
+		t.Errorf("got migrated %v after the retry, want %v", got, want)
+	}
+	if data.SourceStateVersionId.ValueString() != "sv-old-3" || !data.StateContentsSha256.Equal(sha256Contents([]byte(testStateMigrationState(3)))) {
+		t.Errorf("got source %s and checksum %s, want sv-old-3 and its checksum", data.SourceStateVersionId, data.StateContentsSha256)
+	}
+
+	// An update copies only the new current state.
+	f.addState("old", testStateMigrationState(4))
+	prior := *data
+	plan := newTestStateMigrationModel(t, "old", "new")
+	if diags := r.migrate(ctx, plan, &prior); diags.HasError() {
+		t.Fatal(diags)
+	}
+	want = append(want, version{"sv-old-4", "sv-new-4", 4})
+	if got := versions(plan); !slices.Equal(got, want) {
+		t.Errorf("got migrated %v after an update, want %v", got, want)
+	}
+
+	var serials []int64
+	for _, ver := range f.states("new") {
+		serials = append(serials, ver.serial)
+	}
+	if want := []int64{1, 2, 3, 4}; !slices.Equal(serials, want) {
+		t.Errorf("got destination serials %v, want %v", serials, want)
+	}
+	if f.workspaces["old"].locked || f.workspaces["new"].locked {
+		t.Error("got a workspace left locked")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+type uploadStateOptions struct {
+	WorkspaceId string
+	Contents    []byte
+
+	// LockReason is shown on the workspace while it is locked for the upload.
+	LockReason string
+
+	// AllowLineageMismatch uploads the state even when its lineage differs
+	// from the lineage of the workspace's current state.
+	AllowLineageMismatch bool
+}
+
+// uploadStateResult describes the state version created by uploadStateVersion.
+type uploadStateResult struct {
+	StateVersion         *tfe.StateVersion
+	PreviousStateVersion *tfe.StateVersion
+	Serial               int64
+	Lineage              string
+
+	// Contents is the uploaded state, which differs from the given contents
+	// when the serial was raised.
+	Contents []byte
+}
+
+// uploadStateVersion creates a new state version in a workspace from the
+// contents of a state file. The workspace is locked around the upload, and
+// the serial is raised above the current serial so that the uploaded state
+// becomes the current one.
+func uploadStateVersion(ctx context.Context, client *tfe.Client, cache *stateCache, o *uploadStateOptions) (result uploadStateResult, diag diag.Diagnostics) {
+	state, err := parseStateFile(o.Contents)
+	if err != nil {
+		diag.AddError("state", err.Error())
+		return
+	}
+
+	_, err = client.Workspaces.Lock(ctx, o.WorkspaceId, tfe.WorkspaceLockOptions{Reason: tfe.String(o.LockReason)})
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to lock workspace %s: %s", o.WorkspaceId, err))
+		return
+	}
+	defer func() {
+		if _, err := client.Workspaces.Unlock(context.WithoutCancel(ctx), o.WorkspaceId); err != nil {
+			diag.AddError("tfe client", fmt.Sprintf("failed to unlock workspace %s: %s", o.WorkspaceId, err))
+		}
+	}()
+
+	serial := state.Serial
+
+	current, err := client.StateVersions.ReadCurrent(ctx, o.WorkspaceId)
+	switch {
+	case errors.Is(err, tfe.ErrResourceNotFound):
+		// The workspace has no state yet, so there is nothing to check against.
+	case err != nil:
+		diag.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", err))
+		return
+	default:
+		contents, err := downloadStateVersion(ctx, client, cache, current)
+		if err != nil {
+			diag.AddError("tfe client", fmt.Sprintf("failed to download state: %s", err))
+			return
+		}
+
+		currentState, err := parseStateFile(contents)
+		if err != nil {
+			diag.AddError("state", err.Error())
+			return
+		}
+
+		if currentState.Lineage != state.Lineage && !o.AllowLineageMismatch {
+			diag.AddError("state", fmt.Sprintf("lineage %q does not match the lineage %q of the current state of workspace %s", state.Lineage, currentState.Lineage, o.WorkspaceId))
+			return
+		}
+
+		serial = max(serial, currentState.Serial+1)
+		result.PreviousStateVersion = current
+	}
+
+	contents := o.Contents
+	if serial != state.Serial {
+		contents, err = withSerial(contents, serial)
+		if err != nil {
+			diag.AddError("state", err.Error())
+			return
+		}
+	}
+
+	sum := md5.Sum(contents)
+
+	ver, err := client.StateVersions.Create(ctx, o.WorkspaceId, tfe.StateVersionCreateOptions{
+		Lineage: tfe.String(state.Lineage),
+		MD5:     tfe.String(hex.EncodeToString(sum[:])),
+		Serial:  tfe.Int64(serial),
+		State:   tfe.String(base64.StdEncoding.EncodeToString(contents)),
+	})
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to create state version: %s", err))
+		return
+	}
+
+	result.StateVersion = ver
+	result.Serial = serial
+	result.Lineage = state.Lineage
+	result.Contents = contents
+
+	return
+}
@@ -0,0 +1,350 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// fakeTfeStates is a tfe with workspaces in the organization acme that
+// stores the state versions created in them. Like tfe it rejects state
+// versions whose md5 or serial do not match their contents, and state
+// versions created in a workspace that is not locked. Workspaces are listed
+// by name, without filtering.
+type fakeTfeStates struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu         sync.Mutex
+	workspaces map[string]*fakeTfeWorkspace
+
+	// failCreate fails the creation of a state version with the given
+	// serial.
+	failCreate map[int64]bool
+
+	// failDownload fails the download of every state version of the
+	// workspace with the given name.
+	failDownload map[string]bool
+}
+
+type fakeTfeWorkspace struct {
+	id       string
+	name     string
+	locked   bool
+	versions []fakeTfeStateVersion
+}
+
+type fakeTfeStateVersion struct {
+	id       string
+	serial   int64
+	contents []byte
+}
+
+// newFakeTfeStates returns a fake tfe with an empty workspace of each name,
+// the id of which is the name prefixed with ws-.
+func newFakeTfeStates(t *testing.T, names ...string) (*fakeTfeStates, *tfe.Client) {
+	t.Helper()
+
+	f := &fakeTfeStates{t: t, workspaces: make(map[string]*fakeTfeWorkspace), failCreate: make(map[int64]bool), failDownload: make(map[string]bool)}
+	for _, name := range names {
+		f.workspaces[name] = &fakeTfeWorkspace{id: "ws-" + name, name: name}
+	}
+
+	f.srv = httptest.NewServer(f)
+	t.Cleanup(f.srv.Close)
+
+	cfg := tfe.DefaultConfig()
+	cfg.Address = f.srv.URL
+	cfg.Token = "token"
+	configureTfeTransport(cfg, 0, nil)
+
+	client, err := tfe.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f, client
+}
+
+// addState adds a state version to the workspace name without locking it.
+func (f *fakeTfeStates) addState(name string, contents string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state, err := parseStateFile([]byte(contents))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	ws := f.workspaces[name]
+	ws.versions = append(ws.versions, fakeTfeStateVersion{
+		id:       fmt.Sprintf("sv-%s-%d", name, len(ws.versions)+1),
+		serial:   state.Serial,
+		contents: []byte(contents),
+	})
+}
+
+// states returns the state versions of the workspace name, oldest first.
+func (f *fakeTfeStates) states(name string) []fakeTfeStateVersion {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]fakeTfeStateVersion(nil), f.workspaces[name].versions...)
+}
+
+func (f *fakeTfeStates) workspace(id string) *fakeTfeWorkspace {
+	for _, ws := range f.workspaces {
+		if ws.id == id {
+			return ws
+		}
+	}
+	return nil
+}
+
+func (f *fakeTfeStates) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.Header().Set("TFP-API-Version", "2.5")
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v2/"), "/")
+
+	switch {
+	case r.URL.Path == "/api/v2/ping":
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 3 && parts[0] == "organizations" && parts[1] == "acme" && parts[2] == "workspaces":
+		var items []string
+		for _, name := range slices.Sorted(maps.Keys(f.workspaces)) {
+			items = append(items, f.workspaceJSON(f.workspaces[name]))
+		}
+		fmt.Fprintf(w, `{"data":[%s],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`, strings.Join(items, ","))
+
+	case len(parts) == 4 && parts[0] == "organizations" && parts[1] == "acme" && parts[2] == "workspaces":
+		if ws, ok := f.workspaces[parts[3]]; ok {
+			f.writeWorkspace(w, ws)
+			return
+		}
+		f.notFound(w)
+
+	case len(parts) == 4 && parts[0] == "workspaces" && parts[2] == "actions" && r.Method == http.MethodPost:
+		ws := f.workspace(parts[1])
+		if ws == nil {
+			f.notFound(w)
+			return
+		}
+		lock := parts[3] == "lock"
+		if lock == ws.locked {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"errors":[{"status":"409","title":"conflict"}]}`))
+			return
+		}
+		ws.locked = lock
+		f.writeWorkspace(w, ws)
+
+	case len(parts) == 3 && parts[0] == "workspaces" && parts[2] == "current-state-version":
+		ws := f.workspace(parts[1])
+		if ws == nil || len(ws.versions) == 0 {
+			f.notFound(w)
+			return
+		}
+		fmt.Fprintf(w, `{"data":%s}`, f.stateVersionJSON(ws.versions[len(ws.versions)-1]))
+
+	case len(parts) == 3 && parts[0] == "workspaces" && parts[2] == "state-versions" && r.Method == http.MethodPost:
+		f.createStateVersion(w, r, f.workspace(parts[1]))
+
+	case len(parts) == 2 && parts[0] == "state-versions":
+		for _, ws := range f.workspaces {
+			for _, ver := range ws.versions {
+				if ver.id == parts[1] {
+					fmt.Fprintf(w, `{"data":%s}`, f.stateVersionJSON(ver))
+					return
+				}
+			}
+		}
+		f.notFound(w)
+
+	case len(parts) == 1 && parts[0] == "state-versions":
+		ws := f.workspaces[r.URL.Query().Get("filter[workspace][name]")]
+		if ws == nil {
+			f.notFound(w)
+			return
+		}
+		var items []string
+		for i := len(ws.versions) - 1; i >= 0; i-- {
+			items = append(items, f.stateVersionJSON(ws.versions[i]))
+		}
+		fmt.Fprintf(w, `{"data":[%s],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`, strings.Join(items, ","))
+
+	case strings.HasPrefix(r.URL.Path, "/download/"):
+		id := strings.TrimPrefix(r.URL.Path, "/download/")
+		for _, ws := range f.workspaces {
+			for _, ver := range ws.versions {
+				if ver.id == id && !f.failDownload[ws.name] {
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write(ver.contents)
+					return
+				}
+			}
+		}
+		f.notFound(w)
+
+	default:
+		f.notFound(w)
+	}
+}
+
+func (f *fakeTfeStates) createStateVersion(w http.ResponseWriter, r *http.Request, ws *fakeTfeWorkspace) {
+	if ws == nil {
+		f.notFound(w)
+		return
+	}
+
+	var body struct {
+		Data struct {
+			Attributes struct {
+				Serial  int64  `json:"serial"`
+				MD5     string `json:"md5"`
+				Lineage string `json:"lineage"`
+				State   string `json:"state"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		f.unprocessable(w, err.Error())
+		return
+	}
+	attrs := body.Data.Attributes
+
+	contents, err := base64.StdEncoding.DecodeString(attrs.State)
+	if err != nil {
+		f.unprocessable(w, err.Error())
+		return
+	}
+	sum := md5.Sum(contents)
+	state, err := parseStateFile(contents)
+
+	switch {
+	case !ws.locked:
+		f.unprocessable(w, "workspace is not locked")
+	case err != nil:
+		f.unprocessable(w, err.Error())
+	case attrs.MD5 != hex.EncodeToString(sum[:]):
+		f.unprocessable(w, "md5 mismatch")
+	case attrs.Serial != state.Serial || attrs.Lineage != state.Lineage:
+		f.unprocessable(w, "serial or lineage do not match the state")
+	case f.failCreate[attrs.Serial]:
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		ver := fakeTfeStateVersion{id: fmt.Sprintf("sv-%s-%d", ws.name, len(ws.versions)+1), serial: attrs.Serial, contents: contents}
+		ws.versions = append(ws.versions, ver)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"data":%s}`, f.stateVersionJSON(ver))
+	}
+}
+
+func (f *fakeTfeStates) writeWorkspace(w http.ResponseWriter, ws *fakeTfeWorkspace) {
+	fmt.Fprintf(w, `{"data":%s}`, f.workspaceJSON(ws))
+}
+
+func (f *fakeTfeStates) workspaceJSON(ws *fakeTfeWorkspace) string {
+	relationships := ""
+	if len(ws.versions) > 0 {
+		relationships = fmt.Sprintf(`,"relationships":{"current-state-version":{"data":{"id":%q,"type":"state-versions"}}}`, ws.versions[len(ws.versions)-1].id)
+	}
+	return fmt.Sprintf(`{"id":%q,"type":"workspaces","attributes":{"name":%q,"locked":%t}%s}`, ws.id, ws.name, ws.locked, relationships)
+}
+
+func (f *fakeTfeStates) stateVersionJSON(ver fakeTfeStateVersion) string {
+	return fmt.Sprintf(`{"id":%q,"type":"state-versions","attributes":{"serial":%d,"hosted-state-download-url":"%s/download/%s"}}`, ver.id, ver.serial, f.srv.URL, ver.id)
+}
+
+func (f *fakeTfeStates) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write([]byte(`{"errors":[{"status":"404","title":"not found"}]}`))
+}
+
+func (f *fakeTfeStates) unprocessable(w http.ResponseWriter, detail string) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	fmt.Fprintf(w, `{"errors":[{"status":"422","title":"invalid","detail":%q}]}`, detail)
+}
+
+func TestUploadStateVersion(t *testing.T) {
+	const current = `{"version":4,"serial":5,"lineage":"network","resources":[]}`
+	const backup = `{"version":4,"terraform_version":"1.9.0","serial":3,"lineage":"network","outputs":{"vpc_id":{"value":"vpc-1","type":"string"}},"resources":[]}`
+
+	f, client := newFakeTfeStates(t, "network", "empty")
+	f.addState("network", current)
+	ctx := context.Background()
+
+	// A restored backup older than the current state gets the next serial,
+	// keeping the rest of the state as it was.
+	result, diags := uploadStateVersion(ctx, client, nil, &uploadStateOptions{WorkspaceId: "ws-network", Contents: []byte(backup)})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if result.Serial != 6 || result.Lineage != "network" || result.PreviousStateVersion == nil || result.PreviousStateVersion.ID != "sv-network-1" {
+		t.Errorf("got serial %d, lineage %s and previous %+v, want serial 6 after sv-network-1", result.Serial, result.Lineage, result.PreviousStateVersion)
+	}
+
+	states := f.states("network")
+	if len(states) != 2 || states[1].serial != 6 {
+		t.Fatalf("got %d state versions, want the restored one with serial 6", len(states))
+	}
+	uploaded, err := parseStateFile(states[1].contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploaded.Serial != 6 || uploaded.TerraformVersion != "1.9.0" || string(uploaded.Outputs["vpc_id"].Value) != `"vpc-1"` {
+		t.Errorf("got %+v, want the backup with serial 6", uploaded)
+	}
+
+	// Another lineage is only uploaded when allowed.
+	other := strings.Replace(backup, `"lineage":"network"`, `"lineage":"other"`, 1)
+	if _, diags := uploadStateVersion(ctx, client, nil, &uploadStateOptions{WorkspaceId: "ws-network", Contents: []byte(other)}); !diags.HasError() || !strings.Contains(diags[0].Detail(), "lineage") {
+		t.Errorf("got %v, want a lineage mismatch", diags)
+	}
+	if states := f.states("network"); len(states) != 2 {
+		t.Errorf("got %d state versions after a lineage mismatch, want 2", len(states))
+	}
+
+	result, diags = uploadStateVersion(ctx, client, nil, &uploadStateOptions{WorkspaceId: "ws-network", Contents: []byte(other), AllowLineageMismatch: true})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if result.Serial != 7 || result.Lineage != "other" {
+		t.Errorf("got serial %d and lineage %s, want 7 and other", result.Serial, result.Lineage)
+	}
+
+	// A workspace without state takes the state as it is.
+	result, diags = uploadStateVersion(ctx, client, nil, &uploadStateOptions{WorkspaceId: "ws-empty", Contents: []byte(backup)})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if result.Serial != 3 || result.PreviousStateVersion != nil || string(result.Contents) != backup {
+		t.Errorf("got serial %d and contents %s, want the backup unchanged", result.Serial, result.Contents)
+	}
+
+	// Every upload unlocked its workspace again.
+	for _, ws := range f.workspaces {
+		if ws.locked {
+			t.Errorf("got %s locked", ws.name)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &StateVersionDataSource{}
+
+func NewStateVersionDataSource() datasource.DataSource {
+	return &StateVersionDataSource{}
+}
+
+type StateVersionDataSource struct {
+	tfeClient  *tfe.Client
+	stateCache *stateCache
+}
+
+type StateVersionDataSourceModel struct {
+	Id                  types.String `tfsdk:"id"`
+	WorkspaceId         types.String `tfsdk:"workspace_id"`
+	Organization        types.String `tfsdk:"organization"`
+	WorkspaceName       types.String `tfsdk:"workspace_name"`
+	StateVersionId      types.String `tfsdk:"state_version_id"`
+	Serial              types.Int64  `tfsdk:"serial"`
+	Lineage             types.String `tfsdk:"lineage"`
+	TerraformVersion    types.String `tfsdk:"terraform_version"`
+	CreatedAt           types.String `tfsdk:"created_at"`
+	Size                types.Int64  `tfsdk:"size"`
+	ResourceCount       types.Int64  `tfsdk:"resource_count"`
+	DownloadURLSha256   types.String `tfsdk:"download_url_sha256"`
+	DownloadState       types.Bool   `tfsdk:"download_state"`
+	StateContentsSha256 types.String `tfsdk:"state_contents_sha256"`
+}
+
+func (d *StateVersionDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_state_version"
+}
+
+func (d *StateVersionDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Data source to read the metadata of a workspace's tf-state without syncing it",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "state version id",
+				Computed:            true,
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id. Conflicts with `workspace_name`",
+				Optional:            true,
+				Computed:            true,
+				Validators: append(workspaceIdValidators(),
+					stringvalidator.ExactlyOneOf(path.MatchRoot("workspace_name")),
+				),
+			},
+			"organization": schema.StringAttribute{
+				MarkdownDescription: "terraform organization of `workspace_name`",
+				Optional:            true,
+			},
+			"workspace_name": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace name. Requires `organization`",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("organization")),
+				},
+			},
+			"state_version_id": schema.StringAttribute{
+				MarkdownDescription: "state version to read. Defaults to the workspace's current state version",
+				Optional:            true,
+			},
+			"serial": schema.Int64Attribute{
+				MarkdownDescription: "state serial",
+				Computed:            true,
+			},
+			"lineage": schema.StringAttribute{
+				MarkdownDescription: "state lineage, read from the beginning of the state",
+				Computed:            true,
+			},
+			"terraform_version": schema.StringAttribute{
+				MarkdownDescription: "terraform version that wrote the state",
+				Computed:            true,
+			},
+			"created_at": schema.StringAttribute{
+				MarkdownDescription: "RFC3339 timestamp of when the state version was created",
+				Computed:            true,
+			},
+			"size": schema.Int64Attribute{
+				MarkdownDescription: "size of the state in bytes, null if the server does not report it",
+				Computed:            true,
+			},
+			"resource_count": schema.Int64Attribute{
+				MarkdownDescription: "number of managed resource instances in the state, as processed by terraform. Null while the state has not been processed yet, unless the state is downloaded",
+				Computed:            true,
+			},
+			"download_url_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of the download url of the state version",
+				Computed:            true,
+			},
+			"download_state": schema.BoolAttribute{
+				MarkdownDescription: "download the whole state to compute `state_contents_sha256`. Otherwise only its beginning is read. Defaults to `false`",
+				Optional:            true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of tf state, comparable with `state_contents_sha256` of `tfsync_s3_object`. Null unless `download_state` is set or the state was already downloaded by another resource",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *StateVersionDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.tfeClient = data.tfeClient
+	d.stateCache = data.stateCache
+}
+
+func (d *StateVersionDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.tfeClient == nil {
+		resp.Diagnostics.AddError("provider", "nil tfe client")
+		return
+	}
+
+	var data StateVersionDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ws, err := readWorkspace(ctx, d.tfeClient, data.WorkspaceId.ValueString(), data.Organization.ValueString(), data.WorkspaceName.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to read workspace: %s", err))
+		return
+	}
+
+	data.WorkspaceId = types.StringValue(ws.ID)
+
+	ver, diags := readStateVersion(ctx, d.tfeClient, ws.ID, data.StateVersionId.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(ver.ID)
+	data.CreatedAt = types.StringValue(ver.CreatedAt.Format(time.RFC3339))
+	data.DownloadURLSha256 = sha256Contents([]byte(ver.DownloadURL))
+	data.ResourceCount = types.Int64Null()
+	data.StateContentsSha256 = types.StringNull()
+
+	if ver.ResourcesProcessed {
+		var count int64
+		for _, r := range ver.Resources {
+			count += int64(r.Count)
+		}
+		data.ResourceCount = types.Int64Value(count)
+	}
+
+	// The state may have been downloaded by another resource already.
+	contents, downloaded := d.stateCache.lookup(ctx, ver.ID)
+	if !downloaded && data.DownloadState.ValueBool() {
+		contents, err = downloadStateVersion(ctx, d.tfeClient, d.stateCache, ver)
+		if err != nil {
+			resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to download state: %s", err))
+			return
+		}
+		downloaded = true
+	}
+
+	if downloaded {
+		state, err := parseStateFile(contents)
+		if err != nil {
+			resp.Diagnostics.AddError("state", err.Error())
+			return
+		}
+
+		data.Serial = types.Int64Value(state.Serial)
+		data.Lineage = types.StringValue(state.Lineage)
+		data.TerraformVersion = types.StringValue(state.TerraformVersion)
+		data.Size = types.Int64Value(int64(len(contents)))
+		data.StateContentsSha256 = sha256Contents(contents)
+		if !ver.ResourcesProcessed {
+			data.ResourceCount = types.Int64Value(int64(state.resourceCount()))
+		}
+	} else {
+		head, err := readStateHead(ctx, d.tfeClient, ver)
+		if err != nil {
+			resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to read state: %s", err))
+			return
+		}
+
+		data.Serial = types.Int64Value(head.Serial)
+		data.Lineage = types.StringValue(head.Lineage)
+		data.TerraformVersion = types.StringValue(head.TerraformVersion)
+		data.Size = types.Int64PointerValue(head.Size)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// readStateVersion reads the state version with the given id, or the current
+// state version of the workspace when stateVersionId is empty.
+func readStateVersion(ctx context.Context, client *tfe.Client, workspaceId string, stateVersionId string) (ver *tfe.StateVersion, diag diag.Diagnostics) {
+	var err error
+	if stateVersionId == "" {
+		ver, err = client.StateVersions.ReadCurrent(ctx, workspaceId)
+		if err != nil {
+			diag.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", err))
+		}
+		return
+	}
+
+	ver, err = client.StateVersions.Read(ctx, stateVersionId)
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", err))
+		return
+	}
+
+	// A state version id is unique across workspaces, so check that it
+	// belongs to the configured one.
+	owner, err := readStateVersionWorkspaceId(ctx, client, stateVersionId)
+	if err != nil {
+		diag.AddError("tfe client", fmt.Sprintf("failed to get workspace of state version: %s", err))
+		return
+	}
+	if owner != workspaceId {
+		diag.AddError("tfe client", fmt.Sprintf("state version %s belongs to workspace %s, not %s", stateVersionId, owner, workspaceId))
+		return
+	}
+
+	return
+}
+
+// stateVersionWorkspace is the workspace relation of a state version, which
+// go-tfe does not decode.
+type stateVersionWorkspace struct {
+	ID        string         `jsonapi:"primary,state-versions"`
+	Workspace *tfe.Workspace `jsonapi:"relation,workspace"`
+}
+
+// readStateVersionWorkspaceId returns the id of the workspace a state version
+// belongs to.
+func readStateVersionWorkspaceId(ctx context.Context, client *tfe.Client, stateVersionId string) (string, error) {
+	req, err := client.NewRequest("GET", fmt.Sprintf("state-versions/%s", url.PathEscape(stateVersionId)), nil)
+	if err != nil {
+		return "", err
+	}
+
+	sv := &stateVersionWorkspace{}
+	if err := req.Do(ctx, sv); err != nil {
+		return "", err
+	}
+	if sv.Workspace == nil {
+		return "", errors.New("state version has no workspace")
+	}
+
+	return sv.Workspace.ID, nil
+}
+
+// stateHead is what readStateHead learns from the beginning of a state.
+type stateHead struct {
+	TerraformVersion string
+	Serial           int64
+	Lineage          string
+
+	// Size is the Content-Length of the download, or nil if the server does
+	// not send one.
+	Size *int64
+}
+
+// errStateHeadRead stops a download once the head of the state was read.
+var errStateHeadRead = errors.New("read the beginning of the state")
+
+// readStateHead reads the serial, lineage and terraform version of a state
+// version, stopping the download once the lineage is found. Terraform writes
+// them before outputs and resources, so only the first few hundred bytes of a
+// state are read.
+func readStateHead(ctx context.Context, client *tfe.Client, ver *tfe.StateVersion) (head stateHead, err error) {
+	req, err := client.NewRequest("GET", ver.DownloadURL, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// Cancelling the request stops a download waiting for more of the body.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The hook runs before the body is written to pw, and so before done
+	// receives.
+	ctx = tfe.ContextWithResponseHeaderHook(ctx, func(status int, header http.Header) {
+		if size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); status == http.StatusOK && err == nil {
+			head.Size = &size
+		}
+	})
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := req.Do(ctx, pw)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	err = decodeStateHead(json.NewDecoder(pr), &head)
+	pr.CloseWithError(errStateHeadRead)
+	cancel()
+
+	// A failed request also fails decoding, but its error says why.
+	if doErr := <-done; doErr != nil && !errors.Is(doErr, errStateHeadRead) && !errors.Is(doErr, context.Canceled) {
+		return head, doErr
+	}
+
+	return
+}
+
+// decodeStateHead decodes the top-level keys of a state into head up to the
+// lineage, skipping the values of other keys.
+func decodeStateHead(dec *json.Decoder, head *stateHead) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse state: %w", err)
+	}
+	if tok != json.Delim('{') {
+		return errors.New("failed to parse state: not a json object")
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse state: %w", err)
+		}
+
+		var value any = &json.RawMessage{}
+		key, _ := tok.(string)
+		switch key {
+		case "terraform_version":
+			value = &head.TerraformVersion
+		case "serial":
+			value = &head.Serial
+		case "lineage":
+			value = &head.Lineage
+		}
+
+		if err := dec.Decode(value); err != nil {
+			return fmt.Errorf("failed to parse state: %w", err)
+		}
+		if key == "lineage" {
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestReadStateHead(t *testing.T) {
+	client := newFakeTfeClient(t)
+	ctx := context.Background()
+
+	ver, err := client.StateVersions.ReadCurrent(ctx, "ws-abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := readStateHead(ctx, client, ver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Serial != 3 || head.Lineage != "lineage" || head.Size == nil || *head.Size != int64(len(fakeTfeState)) {
+		t.Errorf("got %+v, want serial 3, lineage lineage and size %d", head, len(fakeTfeState))
+	}
+
+	// The download stops after the lineage, without waiting for the
+	// resources.
+	stopped := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":4,"terraform_version":"1.9.0","serial":7,"outputs":{"a":{"value":1}},"lineage":"streamed","resources":[`))
+		w.(http.Flusher).Flush() //nolint:forcetypeassert
+
+		select {
+		case <-r.Context().Done():
+			stopped <- true
+		case <-time.After(10 * time.Second):
+			stopped <- false
+			_, _ = w.Write([]byte(`]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	head, err = readStateHead(ctx, client, &tfe.StateVersion{DownloadURL: srv.URL + "/state"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.TerraformVersion != "1.9.0" || head.Serial != 7 || head.Lineage != "streamed" || head.Size != nil {
+		t.Errorf("got %+v, want terraform 1.9.0, serial 7, lineage streamed and no size", head)
+	}
+	if !<-stopped {
+		t.Error("got the whole state downloaded")
+	}
+
+	missing := &tfe.StateVersion{DownloadURL: strings.TrimSuffix(ver.DownloadURL, "/state") + "/missing"}
+	if _, err := readStateHead(ctx, client, missing); err == nil {
+		t.Error("got no error for a missing state")
+	}
+}
@@ -3,17 +3,73 @@
 package provider
 
 import (
-	"fmt"
-	"net/url"
+	"slices"
 	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// newTags encodes tags for the x-amz-tagging header as a query string with
+// keys in sorted order. url.QueryEscape is not used as it encodes spaces as
+// "+", which S3 stores literally.
 func newTags(tags map[string]string) string {
-	var tagPairs []string
+	var b strings.Builder
+	for i, tag := range sortedTags(tags) {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(escapeTag(aws.ToString(tag.Key)))
+		b.WriteByte('=')
+		b.WriteString(escapeTag(aws.ToString(tag.Value)))
+	}
+
+	return b.String()
+}
+
+// sortedTags returns tags as an s3 tag set with keys in sorted order. Both the
+// x-amz-tagging header and PutObjectTagging use it so that they agree.
+func sortedTags(tags map[string]string) []s3types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	tagSet := make([]s3types.Tag, 0, len(keys))
+	for _, k := range keys {
+		tagSet = append(tagSet, s3types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+
+	return tagSet
+}
+
+// escapeTag percent-encodes every byte of s outside the RFC 3986 unreserved
+// set.
+func escapeTag(s string) string {
+	const hex = "0123456789ABCDEF"
 
-	for k, v := range tags {
-		tagPairs = append(tagPairs, fmt.Sprintf("%s=%s", url.QueryEscape(k), url.QueryEscape(v)))
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isUnreserved(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
 	}
 
-	return strings.Join(tagPairs, "&")
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	case c == '-', c == '.', c == '_', c == '~':
+		return true
+	}
+	return false
 }
@@ -0,0 +1,199 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/xml"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3Tagging stores the x-amz-tagging header of PutObject and serves it
+// back from GetObjectTagging. Like s3, it only decodes percent-encoding, so a
+// "+" is kept as is rather than read as a space.
+type fakeS3Tagging struct {
+	mu   sync.Mutex
+	tags map[string][]fakeS3Tag
+}
+
+type fakeS3Tag struct {
+	Key   string
+	Value string
+}
+
+func (f *fakeS3Tagging) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPut && !r.URL.Query().Has("tagging"):
+		var tags []fakeS3Tag
+		if header := r.Header.Get("X-Amz-Tagging"); header != "" {
+			for _, pair := range strings.Split(header, "&") {
+				k, v, _ := strings.Cut(pair, "=")
+				key, err := url.PathUnescape(k)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				value, err := url.PathUnescape(v)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				tags = append(tags, fakeS3Tag{Key: key, Value: value})
+			}
+		}
+		f.tags[r.URL.Path] = tags
+
+	case r.Method == http.MethodGet && r.URL.Query().Has("tagging"):
+		tags, ok := f.tags[r.URL.Path]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml")
+		_ = xml.NewEncoder(w).Encode(struct {
+			XMLName xml.Name    `xml:"Tagging"`
+			TagSet  []fakeS3Tag `xml:"TagSet>Tag"`
+		}{TagSet: tags})
+
+	default:
+		http.Error(w, "unexpected request", http.StatusNotImplemented)
+	}
+}
+
+func newFakeS3TaggingClient(t testing.TB) *s3.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(&fakeS3Tagging{tags: make(map[string][]fakeS3Tag)})
+	t.Cleanup(srv.Close)
+
+	return s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("test", "test", ""),
+	})
+}
+
+// roundTripTags writes tags with newTags and reads them back.
+func roundTripTags(t testing.TB, client *s3.Client, tags map[string]string) map[string]string {
+	t.Helper()
+
+	ctx := context.Background()
+	_, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:  aws.String("bucket"),
+		Key:     aws.String("key"),
+		Body:    strings.NewReader("{}"),
+		Tagging: aws.String(newTags(tags)),
+	})
+	if err != nil {
+		t.Fatalf("failed to put object: %s", err)
+	}
+
+	out, err := client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+	})
+	if err != nil {
+		t.Fatalf("failed to get object tagging: %s", err)
+	}
+
+	got := make(map[string]string, len(out.TagSet))
+	for _, tag := range out.TagSet {
+		got[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return got
+}
+
+func TestNewTags(t *testing.T) {
+	client := newFakeS3TaggingClient(t)
+
+	for name, tags := range map[string]map[string]string{
+		"empty":     {},
+		"spaces":    {"team name": "platform team", " leading": "trailing "},
+		"plus":      {"a+b": "1+1", "+": "+"},
+		"equals":    {"a=b": "x=y", "=": "=="},
+		"ampersand": {"a&b": "x&y", "&": "&&"},
+		"percent":   {"a%20b": "100%", "%": "%2B"},
+		"unicode":   {"café": "naïve", "日本": "東京", "emoji": "🚀"},
+		"reserved":  {"path": "a/b:c@d", "-_.~": "-_.~"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := roundTripTags(t, client, tags); !maps.Equal(got, tags) {
+				t.Errorf("got %q, want %q", got, tags)
+			}
+		})
+	}
+}
+
+func TestNewTagsOrder(t *testing.T) {
+	tags := map[string]string{"b": "2", "a": "1", "c": "3", "a b": "4", "A": "5"}
+
+	want := "A=5&a=1&a%20b=4&b=2&c=3"
+	for range 100 {
+		if got := newTags(tags); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+
+	keys := make([]string, 0, len(tags))
+	for _, tag := range sortedTags(tags) {
+		keys = append(keys, aws.ToString(tag.Key))
+	}
+	if !slices.IsSorted(keys) {
+		t.Errorf("got keys %q, want them sorted", keys)
+	}
+}
+
+func FuzzNewTags(f *testing.F) {
+	f.Add("key", "value", "other", "")
+	f.Add("a b", "c+d", "e=f", "g&h")
+	f.Add("%41", "%", "café", "🚀")
+
+	client := newFakeS3TaggingClient(f)
+
+	f.Fuzz(func(t *testing.T, k1, v1, k2, v2 string) {
+		tags := map[string]string{k1: v1, k2: v2}
+		for k, v := range tags {
+			if k == "" || !xmlSafeTag(k) || !xmlSafeTag(v) {
+				t.Skip()
+			}
+		}
+
+		if got := roundTripTags(t, client, tags); !maps.Equal(got, tags) {
+			t.Errorf("got %q, want %q", got, tags)
+		}
+	})
+}
+
+// xmlSafeTag reports whether s survives the xml of GetObjectTagging, which
+// cannot carry invalid utf-8 or control characters.
+func xmlSafeTag(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) && r != ' ' {
+			return false
+		}
+	}
+	return true
+}
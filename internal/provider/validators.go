@@ -0,0 +1,278 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+var _ validator.String = s3BucketNameValidator{}
+var _ validator.String = gitPathValidator{}
+var _ validator.String = noPrefixValidator{}
+var _ validator.String = regexpValidator{}
+
+var (
+	workspaceIdRegexp   = regexp.MustCompile(`^ws-[a-zA-Z0-9]+$`)
+	s3BucketRegexp      = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*[a-z0-9]$`)
+	s3TagRegexp         = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+	httpAddressRegexp   = regexp.MustCompile(`^https?://`)
+	ociRepositoryRegexp = regexp.MustCompile(`^[a-zA-Z0-9.-]+(:[0-9]+)?/[a-z0-9]+([._/-][a-z0-9]+)*$`)
+	awsRegionRegexp     = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+	iamRoleArnRegexp    = regexp.MustCompile(`^arn:aws[a-z-]*:iam::[0-9]{12}:role/[\w+=,.@/-]+$`)
+	kmsKeyIdRegexp      = regexp.MustCompile(`^(` +
+		`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}` +
+		`|mrk-[0-9a-f]{32}` +
+		`|alias/[a-zA-Z0-9/_-]+` +
+		`|arn:aws[a-z-]*:kms:[a-z0-9-]+:[0-9]{12}:(key/([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|mrk-[0-9a-f]{32})|alias/[a-zA-Z0-9/_-]+)` +
+		`)$`)
+
+	s3BucketForbiddenPrefixes = []string{"xn--", "sthree-", "amzn-s3-demo-"}
+	s3BucketForbiddenSuffixes = []string{"-s3alias", "--ol-s3", ".mrap", "--x-s3", "--table-s3"}
+	s3KeyForbiddenPrefixes    = []string{"/", "./", "../"}
+)
+
+const (
+	s3MaxKeyBytes       = 1024
+	s3MaxTags           = 10
+	s3MaxTagKeyLength   = 128
+	s3MaxTagValueLength = 256
+)
+
+func workspaceIdValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.RegexMatches(workspaceIdRegexp, "must be a workspace id of the form ws-<id>"),
+	}
+}
+
+func s3BucketValidators() []validator.String {
+	return []validator.String{
+		s3BucketNameValidator{},
+	}
+}
+
+func s3KeyValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.LengthBetween(1, s3MaxKeyBytes),
+		noPrefixValidator{prefixes: s3KeyForbiddenPrefixes},
+	}
+}
+
+func kmsKeyIdValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.RegexMatches(kmsKeyIdRegexp, "must be a kms key id, key arn, alias name or alias arn"),
+	}
+}
+
+func awsRegionValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.RegexMatches(awsRegionRegexp, "must be an aws region, e.g. eu-west-1"),
+	}
+}
+
+func iamRoleArnValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.RegexMatches(iamRoleArnRegexp, "must be an iam role arn"),
+	}
+}
+
+func httpAddressValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.RegexMatches(httpAddressRegexp, "must be an http or https url"),
+	}
+}
+
+func ociRepositoryValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.RegexMatches(ociRepositoryRegexp, "must be a registry host followed by a lowercase repository name"),
+	}
+}
+
+func httpMethodValidators() []validator.String {
+	return []validator.String{
+		stringvalidator.LengthAtLeast(1),
+	}
+}
+
+func s3TagsValidators() []validator.Map {
+	return []validator.Map{
+		mapvalidator.SizeAtMost(s3MaxTags),
+		mapvalidator.KeysAre(
+			stringvalidator.UTF8LengthBetween(1, s3MaxTagKeyLength),
+			stringvalidator.RegexMatches(s3TagRegexp, "must only contain letters, numbers, spaces and _ . : / = + - @"),
+			noPrefixValidator{prefixes: []string{"aws:"}},
+		),
+		mapvalidator.ValueStringsAre(
+			stringvalidator.UTF8LengthAtMost(s3MaxTagValueLength),
+			stringvalidator.RegexMatches(s3TagRegexp, "must only contain letters, numbers, spaces and _ . : / = + - @"),
+		),
+	}
+}
+
+// gcsMetadataValidators rejects the metadata keys the gcs destination uses
+// to record the checksum and workspace of an object.
+func gcsMetadataValidators() []validator.Map {
+	return []validator.Map{
+		mapvalidator.KeysAre(
+			stringvalidator.NoneOf(gcsMetadataSha256, gcsMetadataWorkspaceId),
+		),
+	}
+}
+
+// s3BucketNameValidator checks the general purpose bucket naming rules
+// documented at https://docs.aws.amazon.com/AmazonS3/latest/userguide/bucketnamingrules.html.
+type s3BucketNameValidator struct{}
+
+func (v s3BucketNameValidator) Description(ctx context.Context) string {
+	return "value must be a valid s3 bucket name"
+}
+
+func (v s3BucketNameValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v s3BucketNameValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	name := req.ConfigValue.ValueString()
+
+	if problem := s3BucketNameProblem(name); problem != "" {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid S3 Bucket Name", fmt.Sprintf("Attribute %s %s, got: %s", req.Path, problem, name))
+	}
+}
+
+func s3BucketNameProblem(name string) string {
+	switch {
+	case len(name) < 3 || len(name) > 63:
+		return "must be between 3 and 63 characters long"
+	case !s3BucketRegexp.MatchString(name):
+		return "must only contain lowercase letters, numbers, dots and hyphens, and begin and end with a letter or number"
+	case strings.Contains(name, ".."):
+		return "must not contain two adjacent periods"
+	case net.ParseIP(name) != nil:
+		return "must not be formatted as an IP address"
+	}
+
+	for _, p := range s3BucketForbiddenPrefixes {
+		if strings.HasPrefix(name, p) {
+			return fmt.Sprintf("must not begin with %q", p)
+		}
+	}
+
+	for _, s := range s3BucketForbiddenSuffixes {
+		if strings.HasSuffix(name, s) {
+			return fmt.Sprintf("must not end with %q", s)
+		}
+	}
+
+	return ""
+}
+
+// gitPathValidator requires a path that stays within the work tree of a
+// repository.
+type gitPathValidator struct{}
+
+func (v gitPathValidator) Description(ctx context.Context) string {
+	return "value must be a relative path within the repository"
+}
+
+func (v gitPathValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v gitPathValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+
+	if problem := gitPathProblem(value); problem != "" {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("Attribute %s %s, got: %s", req.Path, problem, value))
+	}
+}
+
+// gitPathProblem describes why path would leave the work tree of a
+// repository, or write to its .git directory.
+func gitPathProblem(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return "must be relative to the root of the repository"
+	}
+
+	segments := strings.Split(path, "/")
+	for _, segment := range segments {
+		if segment == "." || segment == ".." {
+			return `must not contain "." or ".." segments`
+		}
+	}
+
+	if strings.EqualFold(segments[0], ".git") {
+		return "must not be within the .git directory"
+	}
+
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "must be a path within the repository"
+	}
+
+	return ""
+}
+
+// noPrefixValidator rejects strings beginning with any of prefixes.
+type noPrefixValidator struct {
+	prefixes []string
+}
+
+func (v noPrefixValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must not begin with any of: %s", strings.Join(v.prefixes, ", "))
+}
+
+func (v noPrefixValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v noPrefixValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+
+	for _, p := range v.prefixes {
+		if strings.HasPrefix(value, p) {
+			resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("Attribute %s must not begin with %q, got: %s", req.Path, p, value))
+			return
+		}
+	}
+}
+
+// regexpValidator requires the value to be a valid regular expression.
+type regexpValidator struct{}
+
+func (v regexpValidator) Description(ctx context.Context) string {
+	return "value must be a valid regular expression"
+}
+
+func (v regexpValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v regexpValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := regexp.Compile(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Regular Expression", fmt.Sprintf("Attribute %s must be a valid regular expression: %s", req.Path, err))
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	workspacePolicyError           = "error"
+	workspacePolicyKeepBackup      = "keep_backup"
+	workspacePolicyRemoveFromState = "remove_from_state"
+	workspacePolicyDeleteBackup    = "delete_backup"
+)
+
+var (
+	// errWorkspaceNoState is returned for a workspace that exists but has
+	// no state yet.
+	errWorkspaceNoState = errors.New("workspace has no state")
+
+	// errWorkspaceMissing is returned for a workspace that does not exist
+	// and is not listed in its organization. A team token only lists the
+	// workspaces it can see, so it may still have been hidden from the token.
+	errWorkspaceMissing = errors.New("workspace not found, it was deleted or is not visible to the token")
+
+	// errWorkspaceAccessDenied is returned when the token is rejected or may
+	// read the workspace but not its state.
+	errWorkspaceAccessDenied = errors.New("access to the workspace state was denied")
+)
+
+func onWorkspaceMissingAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: fmt.Sprintf("what to do when a refresh finds the workspace deleted. `%s` errors, `%s` keeps the resource and its backup, `%s` forgets the resource but keeps its backup, `%s` deletes the backup on the next apply unless soft delete is enabled, in which case the resource is forgotten. Defaults to `%s`, or with `ignore_empty` to ignoring the workspace like one without state", workspacePolicyError, workspacePolicyKeepBackup, workspacePolicyRemoveFromState, workspacePolicyDeleteBackup, workspacePolicyError),
+		Optional:            true,
+		Validators: []validator.String{
+			stringvalidator.OneOf(workspacePolicyError, workspacePolicyKeepBackup, workspacePolicyRemoveFromState, workspacePolicyDeleteBackup),
+		},
+	}
+}
+
+func onAccessDeniedAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: fmt.Sprintf("what to do when a refresh is denied access to the workspace state. `%s` errors, `%s` keeps the resource and its backup, `%s` forgets the resource but keeps its backup. Defaults to `%s`, or with `ignore_empty` to ignoring the workspace like one without state unless the token was rejected", workspacePolicyError, workspacePolicyKeepBackup, workspacePolicyRemoveFromState, workspacePolicyError),
+		Optional:            true,
+		Validators: []validator.String{
+			stringvalidator.OneOf(workspacePolicyError, workspacePolicyKeepBackup, workspacePolicyRemoveFromState),
+		},
+	}
+}
+
+// workspacePolicy returns the policy for unavailable, an error returned by
+// getWorkspaceState, from the configured policies.
+func workspacePolicy(unavailable error, onWorkspaceMissing types.String, onAccessDenied types.String) string {
+	v := onAccessDenied
+	if errors.Is(unavailable, errWorkspaceMissing) {
+		v = onWorkspaceMissing
+	}
+
+	if v.IsNull() || v.IsUnknown() {
+		return workspacePolicyError
+	}
+	return v.ValueString()
+}
+
+// ignoreUnavailable reports whether unavailable is ignored like a workspace
+// without state. Before the policies existed ignore_empty ignored every
+// workspace without a readable current state version unless the token was
+// rejected, which is kept while no policy is configured for unavailable.
+func ignoreUnavailable(unavailable error, ignoreEmpty bool, onWorkspaceMissing types.String, onAccessDenied types.String) bool {
+	v := onAccessDenied
+	if errors.Is(unavailable, errWorkspaceMissing) {
+		v = onWorkspaceMissing
+	}
+
+	return ignoreEmpty && v.IsNull() && !errors.Is(unavailable, tfe.ErrUnauthorized)
+}
+
+// readCurrentStateVersion reads the current state version of a workspace.
+// When there is none, the workspace is read to tell errWorkspaceNoState,
+// errWorkspaceMissing and errWorkspaceAccessDenied apart. tfe also reports
+// workspaces the token cannot read as not found, so a workspace is only
+// missing when it is not listed in organization either, or in any
+// organization visible to the token when organization is "".
+func readCurrentStateVersion(ctx context.Context, client *tfe.Client, workspaces *workspaceListCache, workspaceId string, organization string) (*tfe.StateVersion, error) {
+	ver, err := client.StateVersions.ReadCurrent(ctx, workspaceId)
+	switch {
+	case err == nil:
+		return ver, nil
+	case errors.Is(err, tfe.ErrUnauthorized):
+		return nil, fmt.Errorf("%w: %w", errWorkspaceAccessDenied, err)
+	case !errors.Is(err, tfe.ErrResourceNotFound):
+		return nil, err
+	}
+
+	ws, err := client.Workspaces.ReadByID(ctx, workspaceId)
+	switch {
+	case errors.Is(err, tfe.ErrResourceNotFound):
+		listed, err := workspaces.listed(ctx, workspaceId, organization)
+		switch {
+		case errors.Is(err, tfe.ErrResourceNotFound), errors.Is(err, tfe.ErrUnauthorized):
+			// Not wrapped, as the token was not rejected for the workspace.
+			return nil, fmt.Errorf("%w: failed to list workspaces: %s", errWorkspaceAccessDenied, err)
+		case err != nil:
+			return nil, err
+		case listed:
+			return nil, errWorkspaceAccessDenied
+		}
+		return nil, errWorkspaceMissing
+	case errors.Is(err, tfe.ErrUnauthorized):
+		return nil, fmt.Errorf("%w: %w", errWorkspaceAccessDenied, err)
+	case err != nil:
+		return nil, err
+	}
+
+	// The workspace reports a current state version the token could not
+	// read.
+	if currentStateVersionId(ws) != "" {
+		return nil, errWorkspaceAccessDenied
+	}
+
+	return nil, errWorkspaceNoState
+}
+
+// getWorkspaceState is getStateFile, except that a deleted or inaccessible
+// workspace is returned as unavailable rather than as an error, to be handled
+// by a workspacePolicy.
+func getWorkspaceState(ctx context.Context, client *tfe.Client, cache *stateCache, workspaces *workspaceListCache, workspaceId string, organization string, ignoreEmpty bool) (state []byte, diag diag.Diagnostics, ignored bool, unavailable error) {
+	ver, err := readCurrentStateVersion(ctx, client, workspaces, workspaceId, organization)
+	if err != nil {
+		switch {
+		case ignoreEmpty && errors.Is(err, errWorkspaceNoState):
+			ignored = true
+		case errors.Is(err, errWorkspaceMissing), errors.Is(err, errWorkspaceAccessDenied):
+			unavailable = err
+		default:
+			diag.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", err))
+		}
+		return
+	}
+
+	state, err = downloadStateVersion(ctx, client, cache, ver)
+	if err != nil {
+		if errors.Is(err, tfe.ErrUnauthorized) {
+			unavailable = fmt.Errorf("%w: %w", errWorkspaceAccessDenied, err)
+			return
+		}
+
+		diag.AddError("tfe client", fmt.Sprintf("failed to download state: %s", err))
+		return
+	}
+
+	return
+}
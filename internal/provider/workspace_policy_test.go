@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestReadCurrentStateVersion(t *testing.T) {
+	client := newFakeTfeClient(t)
+	workspaces := newWorkspaceListCache(client)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		workspaceId  string
+		organization string
+		want         error
+	}{
+		{"ws-empty", "", errWorkspaceNoState},
+		{"ws-hidden", "", errWorkspaceAccessDenied},
+		{"ws-hidden", "acme", errWorkspaceAccessDenied},
+		{"ws-deleted", "", errWorkspaceMissing},
+		{"ws-deleted", "acme", errWorkspaceMissing},
+		// Without access to the organization nothing confirms the deletion.
+		{"ws-deleted", "other", errWorkspaceAccessDenied},
+	} {
+		if _, err := readCurrentStateVersion(ctx, client, workspaces, tt.workspaceId, tt.organization); !errors.Is(err, tt.want) {
+			t.Errorf("%s in %q: got %v, want %v", tt.workspaceId, tt.organization, err, tt.want)
+		}
+	}
+
+	ver, err := readCurrentStateVersion(ctx, client, workspaces, "ws-abc", "")
+	if err != nil || ver.ID != "sv-abc" {
+		t.Errorf("got %v (%v), want sv-abc", ver, err)
+	}
+}
+
+func TestWorkspaceListCache(t *testing.T) {
+	client, requests := newCountingFakeTfeClient(t)
+	workspaces := newWorkspaceListCache(client)
+	ctx := context.Background()
+
+	for range 3 {
+		for _, workspaceId := range []string{"ws-hidden", "ws-deleted"} {
+			if _, err := readCurrentStateVersion(ctx, client, workspaces, workspaceId, ""); err == nil {
+				t.Fatalf("%s: got no error", workspaceId)
+			}
+		}
+	}
+
+	for _, path := range []string{"/api/v2/organizations", "/api/v2/organizations/acme/workspaces"} {
+		if n := requests(path); n != 1 {
+			t.Errorf("got %d requests for %s, want 1", n, path)
+		}
+	}
+}
+
+func TestIgnoreUnavailable(t *testing.T) {
+	unset := types.StringNull()
+	keep := types.StringValue(workspacePolicyKeepBackup)
+	rejected := fmt.Errorf("%w: %w", errWorkspaceAccessDenied, tfe.ErrUnauthorized)
+
+	for _, tt := range []struct {
+		unavailable        error
+		ignoreEmpty        bool
+		onWorkspaceMissing types.String
+		onAccessDenied     types.String
+		want               bool
+	}{
+		{errWorkspaceMissing, true, unset, unset, true},
+		{errWorkspaceMissing, false, unset, unset, false},
+		{errWorkspaceMissing, true, keep, unset, false},
+		{errWorkspaceMissing, true, unset, keep, true},
+		{errWorkspaceAccessDenied, true, unset, unset, true},
+		{errWorkspaceAccessDenied, true, unset, keep, false},
+		{rejected, true, unset, unset, false},
+	} {
+		if got := ignoreUnavailable(tt.unavailable, tt.ignoreEmpty, tt.onWorkspaceMissing, tt.onAccessDenied); got != tt.want {
+			t.Errorf("%v with ignore_empty %t, on_workspace_missing %s and on_access_denied %s: got %t, want %t", tt.unavailable, tt.ignoreEmpty, tt.onWorkspaceMissing, tt.onAccessDenied, got, tt.want)
+		}
+	}
+}
+
+func TestGetStateFileIgnoreEmpty(t *testing.T) {
+	client := newFakeTfeClient(t)
+	cache := newStateCache(1<<20, "")
+
+	// Resources without workspace policies ignore any workspace without
+	// state, including deleted ones.
+	for _, workspaceId := range []string{"ws-empty", "ws-deleted"} {
+		_, diags, ignored := getStateFile(context.Background(), client, cache, workspaceId, true)
+		if diags.HasError() || !ignored {
+			t.Errorf("%s: got ignored %t (%v), want ignored", workspaceId, ignored, diags)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &WorkspaceRestoreResource{}
+
+func NewWorkspaceRestoreResource() resource.Resource {
+	return &WorkspaceRestoreResource{}
+}
+
+type WorkspaceRestoreResource struct {
+	tfeClient  *tfe.Client
+	s3Clients  *s3ClientCache
+	stateCache *stateCache
+}
+
+type WorkspaceRestoreResourceModel struct {
+	Id                     types.String   `tfsdk:"id"`
+	WorkspaceId            types.String   `tfsdk:"workspace_id"`
+	Bucket                 types.String   `tfsdk:"bucket"`
+	Key                    types.String   `tfsdk:"key"`
+	VersionId              types.String   `tfsdk:"version_id"`
+	ConfirmWorkspaceName   types.String   `tfsdk:"confirm_workspace_name"`
+	AllowLineageMismatch   types.Bool     `tfsdk:"allow_lineage_mismatch"`
+	PreviousStateVersionId types.String   `tfsdk:"previous_state_version_id"`
+	Serial                 types.Int64    `tfsdk:"serial"`
+	Lineage                types.String   `tfsdk:"lineage"`
+	StateContentsSha256    types.String   `tfsdk:"state_contents_sha256"`
+	BucketContentsSha256   types.String   `tfsdk:"bucket_contents_sha256"`
+	Timeouts               timeouts.Value `tfsdk:"timeouts"`
+}
+
+func (r *WorkspaceRestoreResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_workspace_restore"
+}
+
+func (r *WorkspaceRestoreResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Resource to restore a workspace's tf-state from an s3 object written by `tfsync_s3_object`. The backup is verified against its sha256 checksum and uploaded as a new state version while the workspace is locked. Destroying the resource does not change the workspace state",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "id of the restored state version",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "terraform workspace id to restore into",
+				Required:            true,
+				Validators:          workspaceIdValidators(),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"bucket": schema.StringAttribute{
+				MarkdownDescription: "s3 bucket",
+				Required:            true,
+				Validators:          s3BucketValidators(),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "s3 bucket key",
+				Required:            true,
+				Validators:          s3KeyValidators(),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"version_id": schema.StringAttribute{
+				MarkdownDescription: "s3 object version to restore. Defaults to the latest version",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"confirm_workspace_name": schema.StringAttribute{
+				MarkdownDescription: "when set, the restore fails unless the workspace has this name",
+				Optional:            true,
+			},
+			"allow_lineage_mismatch": schema.BoolAttribute{
+				MarkdownDescription: "restore even if the lineage of the backup differs from the lineage of the workspace's current state",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"previous_state_version_id": schema.StringAttribute{
+				MarkdownDescription: "id of the state version that was current before the restore, null if the workspace had no state",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"serial": schema.Int64Attribute{
+				MarkdownDescription: "serial of the restored state. Raised above the serial of the previous state when necessary",
+				Computed:            true,
+			},
+			"lineage": schema.StringAttribute{
+				MarkdownDescription: "lineage of the restored state",
+				Computed:            true,
+			},
+			"state_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of the restored tf state",
+				Computed:            true,
+			},
+			"bucket_contents_sha256": schema.StringAttribute{
+				MarkdownDescription: "sha256 sum of s3 bucket object contents",
+				Computed:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+			}),
+		},
+	}
+}
+
+func (r *WorkspaceRestoreResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.tfeClient = data.tfeClient
+	r.s3Clients = data.s3Clients
+	r.stateCache = data.stateCache
+}
+
+func (r *WorkspaceRestoreResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	resp.Diagnostics.Append(validateWorkspaceRestoreResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data WorkspaceRestoreResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, d := data.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	workspaceId := data.WorkspaceId.ValueString()
+
+	ws, err := r.tfeClient.Workspaces.ReadByID(ctx, workspaceId)
+	if err != nil {
+		resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to read workspace: %s", err))
+		return
+	}
+
+	if !data.ConfirmWorkspaceName.IsNull() && data.ConfirmWorkspaceName.ValueString() != ws.Name {
+		resp.Diagnostics.AddError("confirm_workspace_name", fmt.Sprintf("workspace %s is named %q, not %q", workspaceId, ws.Name, data.ConfirmWorkspaceName.ValueString()))
+		return
+	}
+
+	client, err := r.s3Clients.forBucket(ctx, data.Bucket.ValueString(), s3ClientOptions{})
+	if err != nil {
+		resp.Diagnostics.AddError("s3 client", err.Error())
+		return
+	}
+
+	contents, d := getVerifiedS3ObjectContents(ctx, client, data.Bucket.ValueString(), data.Key.ValueString(), data.VersionId.ValueString())
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	result, d := uploadStateVersion(ctx, r.tfeClient, r.stateCache, &uploadStateOptions{
+		WorkspaceId:          workspaceId,
+		Contents:             contents,
+		LockReason:           fmt.Sprintf("tfsync: restoring from s3://%s/%s", data.Bucket.ValueString(), data.Key.ValueString()),
+		AllowLineageMismatch: data.AllowLineageMismatch.ValueBool(),
+	})
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(result.StateVersion.ID)
+	data.PreviousStateVersionId = types.StringNull()
+	if result.PreviousStateVersion != nil {
+		data.PreviousStateVersionId = types.StringValue(result.PreviousStateVersion.ID)
+	}
+	data.Serial = types.Int64Value(result.Serial)
+	data.Lineage = types.StringValue(result.Lineage)
+	data.StateContentsSha256 = sha256Contents(result.Contents)
+	data.BucketContentsSha256 = sha256Contents(contents)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WorkspaceRestoreResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	resp.Diagnostics.Append(validateWorkspaceRestoreResource(r)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var data WorkspaceRestoreResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The restore is a one-off action; it is only undone if the restored
+	// state version is deleted along with its workspace.
+	_, err := r.tfeClient.StateVersions.Read(ctx, data.Id.ValueString())
+	if errors.Is(err, tfe.ErrResourceNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to get state version: %s", err))
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WorkspaceRestoreResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state WorkspaceRestoreResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only confirm_workspace_name and timeouts can change without replacing
+	// the resource, neither of which affects the restored state.
+	state.ConfirmWorkspaceName = plan.ConfirmWorkspaceName
+	state.Timeouts = plan.Timeouts
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *WorkspaceRestoreResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data WorkspaceRestoreResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.AddWarning("restore not undone", fmt.Sprintf("workspace %s keeps state version %s", data.WorkspaceId.ValueString(), data.Id.ValueString()))
+}
+
+func validateWorkspaceRestoreResource(r *WorkspaceRestoreResource) (diag diag.Diagnostics) {
+	if r == nil {
+		diag.AddError("provider", "nil receiver")
+		return
+	}
+
+	if r.s3Clients == nil {
+		diag.AddError("provider", "nil s3 client cache")
+		return
+	}
+
+	if r.tfeClient == nil {
+		diag.AddError("provider", "nil tfe client")
+		return
+	}
+
+	return
+}
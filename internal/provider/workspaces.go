@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+type workspaceFilter struct {
+	Name        string
+	Tags        []string
+	ExcludeTags []string
+	ProjectId   string
+	TagBindings map[string]string
+
+	// Include lists the related resources to read with each workspace.
+	Include []tfe.WSIncludeOpt
+}
+
+// listWorkspaces returns every workspace in organization matching filter.
+func listWorkspaces(ctx context.Context, client *tfe.Client, organization string, filter workspaceFilter) ([]*tfe.Workspace, error) {
+	opts := &tfe.WorkspaceListOptions{
+		ListOptions:  tfe.ListOptions{PageSize: 100},
+		WildcardName: filter.Name,
+		Tags:         strings.Join(filter.Tags, ","),
+		ExcludeTags:  strings.Join(filter.ExcludeTags, ","),
+		ProjectID:    filter.ProjectId,
+		Include:      filter.Include,
+	}
+
+	keys := make([]string, 0, len(filter.TagBindings))
+	for k := range filter.TagBindings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		opts.TagBindings = append(opts.TagBindings, &tfe.TagBinding{Key: k, Value: filter.TagBindings[k]})
+	}
+
+	var workspaces []*tfe.Workspace
+	for {
+		list, err := client.Workspaces.List(ctx, organization, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		workspaces = append(workspaces, list.Items...)
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			return workspaces, nil
+		}
+		opts.PageNumber = list.NextPage
+	}
+}
+
+// listOrganizations returns the names of every organization visible to the
+// token.
+func listOrganizations(ctx context.Context, client *tfe.Client) ([]string, error) {
+	opts := &tfe.OrganizationListOptions{ListOptions: tfe.ListOptions{PageSize: 100}}
+
+	var organizations []string
+	for {
+		list, err := client.Organizations.List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, org := range list.Items {
+			organizations = append(organizations, org.Name)
+		}
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			return organizations, nil
+		}
+		opts.PageNumber = list.NextPage
+	}
+}
+
+// workspaceListCache lists the workspaces of each organization at most once
+// per run and shares the listing between resources, so that confirming that
+// workspaces were deleted costs one listing per organization rather than one
+// per resource.
+type workspaceListCache struct {
+	client *tfe.Client
+
+	mu            sync.Mutex
+	organizations *workspaceListing
+	workspaces    map[string]*workspaceListing
+}
+
+// workspaceListing is a listing shared by concurrent callers. Its mutex is
+// held while the listing is made, so that callers wait for it rather than
+// listing again.
+type workspaceListing struct {
+	mu    sync.Mutex
+	done  bool
+	names map[string]bool
+	err   error
+}
+
+func newWorkspaceListCache(client *tfe.Client) *workspaceListCache {
+	return &workspaceListCache{
+		client:        client,
+		organizations: &workspaceListing{},
+		workspaces:    make(map[string]*workspaceListing),
+	}
+}
+
+// listed reports whether workspaceId is listed in organization, or in any
+// organization visible to the token when organization is "". Without a
+// visible organization nothing can be confirmed, which is returned as
+// tfe.ErrUnauthorized.
+func (c *workspaceListCache) listed(ctx context.Context, workspaceId string, organization string) (bool, error) {
+	organizations := map[string]bool{organization: true}
+	if organization == "" {
+		var err error
+		organizations, err = c.organizations.get(ctx, func(ctx context.Context) ([]string, error) {
+			return listOrganizations(ctx, c.client)
+		})
+		if err != nil {
+			return false, err
+		}
+		if len(organizations) == 0 {
+			return false, tfe.ErrUnauthorized
+		}
+	}
+
+	for organization := range organizations {
+		c.mu.Lock()
+		listing, ok := c.workspaces[organization]
+		if !ok {
+			listing = &workspaceListing{}
+			c.workspaces[organization] = listing
+		}
+		c.mu.Unlock()
+
+		ids, err := listing.get(ctx, func(ctx context.Context) ([]string, error) {
+			workspaces, err := listWorkspaces(ctx, c.client, organization, workspaceFilter{})
+			if err != nil {
+				return nil, err
+			}
+
+			ids := make([]string, 0, len(workspaces))
+			for _, ws := range workspaces {
+				ids = append(ids, ws.ID)
+			}
+			return ids, nil
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if ids[workspaceId] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// get returns the names listed by list, calling it on first use. Errors are
+// kept for the run like listings, except for the cancellation of a caller.
+func (l *workspaceListing) get(ctx context.Context, list func(context.Context) ([]string, error)) (map[string]bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return l.names, l.err
+	}
+
+	names, err := list(ctx)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, err
+	}
+
+	l.done = true
+	l.err = err
+	l.names = make(map[string]bool, len(names))
+	for _, name := range names {
+		l.names[name] = true
+	}
+
+	return l.names, l.err
+}
+
+// currentStateVersionId returns the id of the workspace's current state
+// version as reported by the workspace relationships, or "" if it has none.
+func currentStateVersionId(ws *tfe.Workspace) string {
+	if ws.CurrentStateVersion == nil {
+		return ""
+	}
+	return ws.CurrentStateVersion.ID
+}
+
+// readWorkspace reads a workspace by id, or by organization and name when
+// workspaceId is empty.
+func readWorkspace(ctx context.Context, client *tfe.Client, workspaceId string, organization string, name string) (*tfe.Workspace, error) {
+	if workspaceId != "" {
+		return client.Workspaces.ReadByID(ctx, workspaceId)
+	}
+	return client.Workspaces.Read(ctx, organization, name)
+}
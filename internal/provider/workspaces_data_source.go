@@ -0,0 +1,294 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &WorkspacesDataSource{}
+
+func NewWorkspacesDataSource() datasource.DataSource {
+	return &WorkspacesDataSource{}
+}
+
+type WorkspacesDataSource struct {
+	tfeClient *tfe.Client
+}
+
+type WorkspacesDataSourceModel struct {
+	Id                   types.String `tfsdk:"id"`
+	Organization         types.String `tfsdk:"organization"`
+	WorkspaceName        types.String `tfsdk:"workspace_name"`
+	WorkspaceNameRegex   types.String `tfsdk:"workspace_name_regex"`
+	WorkspaceTags        types.List   `tfsdk:"workspace_tags"`
+	ExcludeWorkspaceTags types.List   `tfsdk:"exclude_workspace_tags"`
+	TagBindings          types.Map    `tfsdk:"tag_bindings"`
+	ProjectId            types.String `tfsdk:"project_id"`
+	HasState             types.Bool   `tfsdk:"has_state"`
+	Ids                  types.Map    `tfsdk:"ids"`
+	Workspaces           types.List   `tfsdk:"workspaces"`
+}
+
+type workspaceModel struct {
+	Id            types.String `tfsdk:"id"`
+	Name          types.String `tfsdk:"name"`
+	ProjectId     types.String `tfsdk:"project_id"`
+	Tags          types.List   `tfsdk:"tags"`
+	TagBindings   types.Map    `tfsdk:"tag_bindings"`
+	ExecutionMode types.String `tfsdk:"execution_mode"`
+	HasState      types.Bool   `tfsdk:"has_state"`
+	CurrentSerial types.Int64  `tfsdk:"current_serial"`
+}
+
+var workspaceAttrTypes = map[string]attr.Type{
+	"id":             types.StringType,
+	"name":           types.StringType,
+	"project_id":     types.StringType,
+	"tags":           types.ListType{ElemType: types.StringType},
+	"tag_bindings":   types.MapType{ElemType: types.StringType},
+	"execution_mode": types.StringType,
+	"has_state":      types.BoolType,
+	"current_serial": types.Int64Type,
+}
+
+func (d *WorkspacesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_workspaces"
+}
+
+func (d *WorkspacesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Data source to list the workspaces of an organization, e.g. to sync only the workspaces that have state with `tfsync_s3_object`",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "organization name",
+				Computed:            true,
+			},
+			"organization": schema.StringAttribute{
+				MarkdownDescription: "terraform organization",
+				Required:            true,
+			},
+			"workspace_name": schema.StringAttribute{
+				MarkdownDescription: "only list workspaces matching this name. `*` matches any characters, e.g. `prod-*`",
+				Optional:            true,
+			},
+			"workspace_name_regex": schema.StringAttribute{
+				MarkdownDescription: "only list workspaces whose name matches this regular expression",
+				Optional:            true,
+				Validators: []validator.String{
+					regexpValidator{},
+				},
+			},
+			"workspace_tags": schema.ListAttribute{
+				MarkdownDescription: "only list workspaces with all of these tags",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"exclude_workspace_tags": schema.ListAttribute{
+				MarkdownDescription: "do not list workspaces with any of these tags",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"tag_bindings": schema.MapAttribute{
+				MarkdownDescription: "only list workspaces with all of these key/value tags, including tags inherited from the project. An empty value matches any value",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"project_id": schema.StringAttribute{
+				MarkdownDescription: "only list workspaces in this project",
+				Optional:            true,
+			},
+			"has_state": schema.BoolAttribute{
+				MarkdownDescription: "only list workspaces that have (`true`) or do not have (`false`) a current state version",
+				Optional:            true,
+			},
+			"ids": schema.MapAttribute{
+				MarkdownDescription: "workspace ids by workspace name, for use with `for_each`",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"workspaces": schema.ListNestedAttribute{
+				MarkdownDescription: "matching workspaces, ordered by name",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace id",
+							Computed:            true,
+						},
+						"name": schema.StringAttribute{
+							MarkdownDescription: "terraform workspace name",
+							Computed:            true,
+						},
+						"project_id": schema.StringAttribute{
+							MarkdownDescription: "project id",
+							Computed:            true,
+						},
+						"tags": schema.ListAttribute{
+							MarkdownDescription: "tag names",
+							Computed:            true,
+							ElementType:         types.StringType,
+						},
+						"tag_bindings": schema.MapAttribute{
+							MarkdownDescription: "key/value tags, including tags inherited from the project",
+							Computed:            true,
+							ElementType:         types.StringType,
+						},
+						"execution_mode": schema.StringAttribute{
+							MarkdownDescription: "execution mode, e.g. `remote`, `local` or `agent`",
+							Computed:            true,
+						},
+						"has_state": schema.BoolAttribute{
+							MarkdownDescription: "true if the workspace has a current state version",
+							Computed:            true,
+						},
+						"current_serial": schema.Int64Attribute{
+							MarkdownDescription: "serial of the current state version, null if the workspace has no state",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *WorkspacesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*ResourceConfigureData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ResourceConfigureData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.tfeClient = data.tfeClient
+}
+
+func (d *WorkspacesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.tfeClient == nil {
+		resp.Diagnostics.AddError("provider", "nil tfe client")
+		return
+	}
+
+	var data WorkspacesDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filter := workspaceFilter{
+		Name:      data.WorkspaceName.ValueString(),
+		ProjectId: data.ProjectId.ValueString(),
+		Include:   []tfe.WSIncludeOpt{tfe.WSCurrentStateVer, tfe.WSEffectiveTagBindings},
+	}
+	resp.Diagnostics.Append(data.WorkspaceTags.ElementsAs(ctx, &filter.Tags, true)...)
+	resp.Diagnostics.Append(data.ExcludeWorkspaceTags.ElementsAs(ctx, &filter.ExcludeTags, true)...)
+	resp.Diagnostics.Append(data.TagBindings.ElementsAs(ctx, &filter.TagBindings, true)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var nameRegexp *regexp.Regexp
+	if !data.WorkspaceNameRegex.IsNull() {
+		var err error
+		nameRegexp, err = regexp.Compile(data.WorkspaceNameRegex.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("workspace_name_regex", err.Error())
+			return
+		}
+	}
+
+	workspaces, err := listWorkspaces(ctx, d.tfeClient, data.Organization.ValueString(), filter)
+	if err != nil {
+		resp.Diagnostics.AddError("tfe client", fmt.Sprintf("failed to list workspaces: %s", err))
+		return
+	}
+
+	ids := make(map[string]string)
+	models := []workspaceModel{}
+	for _, ws := range workspaces {
+		hasState := ws.CurrentStateVersion != nil
+
+		if nameRegexp != nil && !nameRegexp.MatchString(ws.Name) {
+			continue
+		}
+		if !data.HasState.IsNull() && data.HasState.ValueBool() != hasState {
+			continue
+		}
+
+		m, diags := newWorkspaceModel(ctx, ws)
+		resp.Diagnostics.Append(diags...)
+
+		ids[ws.Name] = ws.ID
+		models = append(models, m)
+	}
+
+	data.Id = types.StringValue(data.Organization.ValueString())
+
+	var diags diag.Diagnostics
+	data.Ids, diags = types.MapValueFrom(ctx, types.StringType, ids)
+	resp.Diagnostics.Append(diags...)
+
+	data.Workspaces, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: workspaceAttrTypes}, models)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func newWorkspaceModel(ctx context.Context, ws *tfe.Workspace) (m workspaceModel, diag diag.Diagnostics) {
+	m.Id = types.StringValue(ws.ID)
+	m.Name = types.StringValue(ws.Name)
+	m.ExecutionMode = types.StringValue(ws.ExecutionMode)
+	m.HasState = types.BoolValue(ws.CurrentStateVersion != nil)
+
+	m.ProjectId = types.StringNull()
+	if ws.Project != nil {
+		m.ProjectId = types.StringValue(ws.Project.ID)
+	}
+
+	// The serial is only populated when the current state version is included
+	// in the workspace list.
+	m.CurrentSerial = types.Int64Null()
+	if ws.CurrentStateVersion != nil {
+		m.CurrentSerial = types.Int64Value(ws.CurrentStateVersion.Serial)
+	}
+
+	tags := append([]string{}, ws.TagNames...)
+
+	tagBindings := make(map[string]string)
+	for _, b := range ws.EffectiveTagBindings {
+		tagBindings[b.Key] = b.Value
+	}
+
+	tagList, d := types.ListValueFrom(ctx, types.StringType, tags)
+	diag.Append(d...)
+	m.Tags = tagList
+
+	m.TagBindings, d = types.MapValueFrom(ctx, types.StringType, tagBindings)
+	diag.Append(d...)
+
+	return
+}
@@ -34,6 +34,11 @@ func main() {
 
 	err := providerserver.Serve(context.Background(), provider.New(version), opts)
 
+	// State files spilled to disk must not outlive the provider.
+	if rerr := provider.RemoveSpilledState(); rerr != nil {
+		log.Printf("failed to remove spilled state: %s", rerr)
+	}
+
 	if err != nil {
 		log.Fatal(err.Error())
 	}